@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/secrets"
+	"github.com/shadow/meme/internal/sources"
+	"github.com/shadow/meme/internal/utils"
+)
+
+// cookieCheckResult 单个 Cookie 的检查结果 (Cookie 已掩码)
+type cookieCheckResult struct {
+	sources.CookieInfo
+	Status string `json:"status"` // ok / auth_failed / error
+	Error  string `json:"error,omitempty"`
+}
+
+// runCookie 抖音 Cookie 管理: check
+func runCookie(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, `用法:
+  meme-cli cookie check [-json] [-t 秒]   # 逐个请求抖音接口，检查 Cookie 是否可用`)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("cookie check", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "输出 JSON 格式")
+	timeout := fs.Int("t", 15, "每个 Cookie 的超时时间(秒)")
+	fs.Parse(args[1:])
+
+	// 结果会逐条输出，不再重复输出警告日志
+	utils.SetLogLevel("", utils.LogLevelError)
+
+	config, err := sources.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败: %v\n", err)
+		return 1
+	}
+	if len(config.DouyinCookies) == 0 {
+		fmt.Fprintln(os.Stderr, "未配置抖音 Cookie (DOUYIN_COOKIE / DOUYIN_COOKIE_FILE / 加密存储)")
+		return 1
+	}
+
+	source := sources.NewDouyinWithPool(sources.NewCookiePool(config.DouyinCookies, config.DouyinCookieRotation))
+	sources.Configure(source, config)
+
+	results := make([]cookieCheckResult, 0, len(config.DouyinCookies))
+	failed := 0
+	for i, info := range source.Cookies().Status() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+		err := source.CheckCookie(ctx, config.DouyinCookies[i])
+		cancel()
+
+		result := cookieCheckResult{CookieInfo: info, Status: "ok"}
+		switch {
+		case errors.Is(err, core.ErrAuthRequired):
+			result.Status = "auth_failed"
+			result.Error = err.Error()
+		case err != nil:
+			result.Status = "error"
+			result.Error = err.Error()
+		}
+		if err != nil {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printCookieResults(results)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printCookieResults(results []cookieCheckResult) {
+	fmt.Printf("🍪 抖音 Cookie 检查 (%d 个)\n\n", len(results))
+	for _, r := range results {
+		icon := "🟢"
+		switch r.Status {
+		case "auth_failed":
+			icon = "🔴"
+		case "error":
+			icon = "🟡"
+		}
+		fmt.Printf("%s #%d %s\n", icon, r.Index, r.Cookie)
+		fmt.Printf("    状态: %s\n", r.Status)
+		if r.Expiry.State != secrets.CookieUnknown {
+			fmt.Printf("    过期: %s (%s)\n", r.Expiry.Expires.Format(time.DateTime), r.Expiry.State)
+		}
+		if r.Error != "" {
+			fmt.Printf("    原因: %s\n", r.Error)
+		}
+		fmt.Println()
+	}
+}
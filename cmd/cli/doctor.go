@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shadow/meme/internal/browser"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/secrets"
+	"github.com/shadow/meme/internal/sources"
+	"github.com/shadow/meme/internal/utils"
+)
+
+// doctorReport doctor 子命令的检查结果，所有敏感信息均已掩码
+type doctorReport struct {
+	Config   *sources.Config      `json:"config,omitempty"`
+	Cookies  []sources.CookieInfo `json:"douyin_cookies,omitempty"`
+	Store    storeReport          `json:"secrets_store"`
+	Env      map[string]string    `json:"env"`
+	Sources  []string             `json:"sources"`
+	TLS      []sources.TLSCheck   `json:"tls,omitempty"`
+	Problems []string             `json:"problems"`
+}
+
+type storeReport struct {
+	Path     string   `json:"path"`
+	Exists   bool     `json:"exists"`
+	Unlocked bool     `json:"unlocked"`
+	Names    []string `json:"names,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// doctorEnv doctor 中展示的非敏感环境变量
+var doctorEnv = []string{
+	"LOG_LEVEL", "LOG_LEVELS", "LOG_FORMAT",
+	"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "MEME_TRACE_FILE",
+	"DOUYIN_COOKIE_FILE", secrets.StorePathEnv, "MEME_DUMP_DIR",
+}
+
+// runDoctor 检查配置并输出诊断信息，发现问题时返回非零退出码
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "输出 JSON 格式")
+	checkTLS := fs.Bool("tls", false, "连接各源站点检查 TLS 握手与证书")
+	fs.Parse(args)
+
+	// 问题会汇总在诊断结果中，不再重复输出警告日志
+	utils.SetLogLevel("", utils.LogLevelError)
+
+	report := doctorReport{Env: make(map[string]string), Problems: []string{}}
+
+	config, err := sources.LoadConfig()
+	if err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("读取配置失败: %v", err))
+	} else {
+		report.Config = config
+		report.Cookies = sources.NewCookiePool(config.DouyinCookies, config.DouyinCookieRotation).Status()
+		for _, info := range report.Cookies {
+			switch {
+			case info.Expiry.State == secrets.CookieExpired:
+				report.Problems = append(report.Problems, fmt.Sprintf("抖音 Cookie #%d 已于 %s 过期", info.Index, info.Expiry.Expires.Format(time.DateTime)))
+			case info.Expiry.State == secrets.CookieExpiring:
+				report.Problems = append(report.Problems, fmt.Sprintf("抖音 Cookie #%d 将于 %s 过期", info.Index, info.Expiry.Expires.Format(time.DateTime)))
+			case !info.Healthy:
+				report.Problems = append(report.Problems, fmt.Sprintf("抖音 Cookie #%d 不可用", info.Index))
+			}
+		}
+
+		registry := core.NewRegistry()
+		sources.RegisterAllSources(registry, config)
+		for _, info := range sources.GetAllSourceInfo(registry) {
+			report.Sources = append(report.Sources, info.ID)
+		}
+
+		if *checkTLS {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+			report.TLS = sources.CheckTLS(ctx, registry)
+			cancel()
+			for _, check := range report.TLS {
+				if !check.OK {
+					report.Problems = append(report.Problems, fmt.Sprintf("%s 连接检查失败: %s", check.Source, check.Error))
+				}
+			}
+		}
+	}
+
+	report.Store = inspectStore()
+	if report.Store.Error != "" {
+		report.Problems = append(report.Problems, "加密存储: "+report.Store.Error)
+	}
+
+	for _, name := range doctorEnv {
+		if value := os.Getenv(name); value != "" {
+			report.Env[name] = secrets.MaskURL(value)
+		}
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printDoctor(report)
+	}
+
+	if len(report.Problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// inspectStore 检查加密存储状态，只列出名称不读取值
+func inspectStore() storeReport {
+	report := storeReport{Path: secrets.DefaultStorePath()}
+	if _, err := os.Stat(report.Path); err != nil {
+		return report
+	}
+	report.Exists = true
+
+	passphrase := os.Getenv(secrets.StoreKeyEnv)
+	if passphrase == "" {
+		return report
+	}
+	store, err := secrets.OpenStore(report.Path, passphrase)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Unlocked = true
+	report.Names = store.Names()
+	return report
+}
+
+func printDoctor(report doctorReport) {
+	fmt.Println("🩺 Meme 配置诊断")
+	fmt.Println()
+
+	fmt.Println("🔐 秘密配置:")
+	if report.Config != nil {
+		if len(report.Cookies) == 0 {
+			fmt.Println("  DOUYIN_COOKIE:   未配置 (抖音源不可用)")
+		} else {
+			fmt.Printf("  DOUYIN_COOKIE:   %d 个 (来源: %s, 轮换: %s)\n", len(report.Cookies), report.Config.DouyinCookieOrigin, rotationName(report.Config.DouyinCookieRotation))
+			for _, info := range report.Cookies {
+				fmt.Printf("    #%d %s  %s\n", info.Index, info.Cookie, describeCookie(info))
+			}
+		}
+		if report.Config.ImageProxyURL == "" {
+			fmt.Println("  IMAGE_PROXY_URL: 未配置 (qudoutu、doutub 不可用)")
+		} else {
+			fmt.Printf("  IMAGE_PROXY_URL: %s\n", secrets.MaskURL(report.Config.ImageProxyURL))
+		}
+	}
+
+	store := report.Store
+	switch {
+	case !store.Exists:
+		fmt.Printf("  加密存储:        %s (不存在)\n", store.Path)
+	case store.Error != "":
+		fmt.Printf("  加密存储:        %s (无法解锁: %s)\n", store.Path, store.Error)
+	case !store.Unlocked:
+		fmt.Printf("  加密存储:        %s (未设置 %s，未解锁)\n", store.Path, secrets.StoreKeyEnv)
+	default:
+		fmt.Printf("  加密存储:        %s (已解锁, 条目: %v)\n", store.Path, store.Names)
+	}
+	fmt.Println()
+
+	if len(report.Env) > 0 {
+		fmt.Println("⚙️  环境变量:")
+		for _, name := range doctorEnv {
+			if value, ok := report.Env[name]; ok {
+				fmt.Printf("  %s=%s\n", name, value)
+			}
+		}
+		fmt.Println()
+	}
+
+	if report.Config != nil && (!report.Config.Egress.IsZero() || len(report.Config.SourceEgress) > 0) {
+		fmt.Println("🛰️  出站网络:")
+		if !report.Config.Egress.IsZero() {
+			fmt.Printf("  %-8s %s\n", "*", describeEgress(report.Config.Egress))
+		}
+		for _, id := range sortedKeys(report.Config.SourceEgress) {
+			fmt.Printf("  %-8s %s\n", id, describeEgress(report.Config.SourceEgress[id]))
+		}
+		fmt.Println()
+	}
+
+	if report.Config != nil && (!report.Config.Browser.IsZero() || len(report.Config.SourceBrowser) > 0) {
+		fmt.Println("🧭 浏览器请求头:")
+		if !report.Config.Browser.IsZero() {
+			fmt.Printf("  %-8s %s\n", "*", describeBrowser(report.Config.Browser))
+		}
+		for _, id := range sortedKeys(report.Config.SourceBrowser) {
+			fmt.Printf("  %-8s %s\n", id, describeBrowser(report.Config.SourceBrowser[id]))
+		}
+		fmt.Println()
+	}
+
+	if report.Config != nil && (!report.Config.Session.IsZero() || len(report.Config.SourceSession) > 0) {
+		fmt.Println("🍪 会话:")
+		if !report.Config.Session.IsZero() {
+			fmt.Printf("  %-8s %s\n", "*", describeSession(report.Config.Session))
+		}
+		for _, id := range sortedKeys(report.Config.SourceSession) {
+			fmt.Printf("  %-8s %s\n", id, describeSession(report.Config.SourceSession[id]))
+		}
+		fmt.Println()
+	}
+
+	if report.Config != nil && len(report.Config.Endpoints) > 0 {
+		fmt.Println("🌐 站点地址覆盖:")
+		for _, id := range sortedKeys(report.Config.Endpoints) {
+			fmt.Printf("  %-8s %s\n", id, describeEndpoint(report.Config.Endpoints[id]))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("📦 已启用的数据源: %v\n\n", report.Sources)
+
+	if len(report.TLS) > 0 {
+		fmt.Println("🔒 TLS 检查:")
+		for _, check := range report.TLS {
+			printTLSCheck(check)
+		}
+		fmt.Println()
+	}
+
+	if len(report.Problems) == 0 {
+		fmt.Println("✅ 未发现问题")
+		return
+	}
+	fmt.Println("⚠️  发现问题:")
+	for _, p := range report.Problems {
+		fmt.Printf("  - %s\n", p)
+	}
+}
+
+func rotationName(r sources.CookieRotation) sources.CookieRotation {
+	if r == "" {
+		return sources.RotationFailover
+	}
+	return r
+}
+
+// describeCookie 描述 Cookie 的本地检查结果
+func describeCookie(info sources.CookieInfo) string {
+	desc := string(info.Expiry.State)
+	if !info.Expiry.Expires.IsZero() {
+		desc += fmt.Sprintf(" (过期时间 %s)", info.Expiry.Expires.Format(time.DateTime))
+	}
+	if info.Reason != "" {
+		desc += ", " + info.Reason
+	} else if !info.Healthy {
+		desc += ", 不可用"
+	}
+	return desc
+}
+
+// describeEndpoint 描述已覆盖的站点地址字段
+func describeEndpoint(e sources.Endpoint) string {
+	var parts []string
+	if e.BaseURL != "" {
+		parts = append(parts, "地址 "+secrets.MaskURL(e.BaseURL))
+	}
+	if e.Referer != "" {
+		parts = append(parts, "Referer "+e.Referer)
+	}
+	if e.UserAgent != "" {
+		parts = append(parts, "User-Agent "+e.UserAgent)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printTLSCheck 输出一个源的握手结果，失败时附带服务器证书与修复建议
+func printTLSCheck(check sources.TLSCheck) {
+	if check.OK {
+		fmt.Printf("  ✅ %-8s %s %s (证书到期 %s)\n", check.Source, check.Host, check.Version, check.NotAfter.Format(time.DateOnly))
+		return
+	}
+	fmt.Printf("  ❌ %-8s %s\n", check.Source, check.Host)
+	fmt.Printf("     错误: %s\n", check.Error)
+	if check.Subject != "" {
+		fmt.Printf("     证书: %s (签发者 %s, 到期 %s)\n", check.Subject, check.Issuer, check.NotAfter.Format(time.DateOnly))
+	}
+	for _, pin := range check.Pins {
+		fmt.Printf("     公钥指纹: %s\n", pin)
+	}
+	if check.Hint != "" {
+		fmt.Printf("     建议: %s\n", check.Hint)
+	}
+}
+
+// describeEgress 描述出站配置，代理密码只显示掩码
+func describeEgress(e sources.Egress) string {
+	var parts []string
+	if e.Proxy != "" {
+		parts = append(parts, "代理 "+secrets.MaskURL(e.Proxy))
+	}
+	if e.DNSServer != "" {
+		parts = append(parts, "DNS "+e.DNSServer)
+	}
+	for _, host := range sortedKeys(e.Resolve) {
+		parts = append(parts, fmt.Sprintf("解析 %s=%s", host, e.Resolve[host]))
+	}
+	if e.BindAddr != "" {
+		parts = append(parts, "绑定 "+e.BindAddr)
+	}
+	if e.CAFile != "" {
+		parts = append(parts, "CA "+e.CAFile)
+	}
+	if len(e.Pins) > 0 {
+		parts = append(parts, fmt.Sprintf("固定指纹 %d 个", len(e.Pins)))
+	}
+	if e.Insecure {
+		parts = append(parts, "⚠️ 不校验证书")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeBrowser 描述浏览器配置，未设置的字段显示默认值
+func describeBrowser(c sources.BrowserConfig) string {
+	profiles := strings.Join(c.Profiles, ",")
+	if profiles == "" {
+		profiles = browser.DefaultName
+	}
+	rotation := c.Rotation
+	if rotation == "" {
+		rotation = browser.RotationFixed
+	}
+	return fmt.Sprintf("%s (%s)", profiles, rotation)
+}
+
+// describeSession 描述会话配置
+func describeSession(c sources.SessionConfig) string {
+	var parts []string
+	if c.Dir != "" {
+		parts = append(parts, "保存目录 "+c.Dir)
+	}
+	if c.WarmUpEnabled() {
+		parts = append(parts, "首页预热")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,15 +5,30 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/shadow/meme/internal/core"
 	"github.com/shadow/meme/internal/sources"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
 )
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "secrets":
+			os.Exit(runSecrets(os.Args[2:]))
+		case "cookie":
+			os.Exit(runCookie(os.Args[2:]))
+		}
+	}
+
 	// 定义命令行参数
 	keyword := flag.String("k", "", "搜索关键词 (必填)")
 	sourceList := flag.String("s", "", "指定源，逗号分隔 (可选，如: pdan,qudoutu)")
@@ -21,7 +36,12 @@ func main() {
 	page := flag.Int("p", 1, "页码")
 	timeout := flag.Int("t", 15, "超时时间(秒)")
 	listSources := flag.Bool("list", false, "列出所有可用源")
+	latest := flag.Bool("latest", false, "浏览最新表情包 (无需关键词)")
 	outputJSON := flag.Bool("json", false, "输出 JSON 格式")
+	stream := flag.Bool("stream", false, "每个源返回时立即输出结果")
+	minResults := flag.Int("min", 0, "收集到 N 个结果后提前返回 (0 表示等待所有源)")
+	deadline := flag.Int("deadline", 0, "整体截止时间(毫秒)，到时返回已收集的结果 (0 表示不限制)")
+	hedge := flag.Int("hedge", 0, "源超过该时间(毫秒)未返回时发起对冲请求 (0 表示不对冲)")
 	verbose := flag.Bool("v", false, "显示详细信息")
 
 	flag.Usage = func() {
@@ -35,6 +55,13 @@ func main() {
   meme-cli -k 狗 -s pdan,qudoutu    # 只从指定源搜索
   meme-cli -k 开心 -l 5 -json       # 输出 JSON 格式
   meme-cli -list                    # 列出所有可用源
+  meme-cli -k 猫 -stream -min 20     # 边搜边输出，收集到 20 个结果即返回
+  meme-cli -latest -l 5             # 浏览最新表情包
+  meme-cli -latest -s doutula       # 浏览指定源的最新表情包
+  meme-cli doctor                   # 检查配置与 Cookie 状态 (敏感信息已掩码)
+  meme-cli doctor -tls              # 同时连接各源站点检查 TLS 证书
+  meme-cli secrets set DOUYIN_COOKIE < cookie.txt  # 保存到加密存储
+  meme-cli cookie check             # 在线检查每个抖音 Cookie 是否可用
 
 选项:
 `)
@@ -43,14 +70,30 @@ func main() {
 
 	flag.Parse()
 
+	// 命令行默认只输出警告以上的日志，避免与结果输出混杂；-v 时输出 debug 日志 (含 HTTP 请求)
+	if *verbose {
+		utils.SetLogLevel("", utils.LogLevelDebug)
+	} else if os.Getenv("LOG_LEVEL") == "" {
+		utils.SetLogLevel("", utils.LogLevelWarn)
+	}
+
+	// 链路追踪 (OTEL_EXPORTER_OTLP_ENDPOINT / MEME_TRACE_FILE)
+	tracer, err := tracing.Setup(tracing.ConfigFromEnv("meme-cli"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化链路追踪失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer tracer.Shutdown(context.Background())
+
 	// 创建注册中心并注册源
 	if *verbose {
 		fmt.Fprintln(os.Stderr, "🔧 初始化注册中心...")
 	}
 	registry := core.NewRegistry()
-	config := &sources.Config{
-		DouyinCookie:  os.Getenv("DOUYIN_COOKIE"),
-		ImageProxyURL: os.Getenv("IMAGE_PROXY_URL"),
+	config, err := sources.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败: %v\n", err)
+		os.Exit(1)
 	}
 	if *verbose {
 		fmt.Fprintln(os.Stderr, "📦 正在注册数据源...")
@@ -69,8 +112,18 @@ func main() {
 		return
 	}
 
-	// 验证关键词
-	if *keyword == "" {
+	// 浏览模式无需关键词
+	var browseMode core.BrowseMode
+	if *latest {
+		browseMode = core.BrowseLatest
+	}
+
+	// 验证关键词：浏览模式与关键词搜索互斥
+	if *keyword != "" && browseMode != "" {
+		fmt.Fprintln(os.Stderr, "错误: -k 不能与 -latest 同时使用")
+		os.Exit(1)
+	}
+	if *keyword == "" && browseMode == "" {
 		fmt.Fprintln(os.Stderr, "错误: 请使用 -k 指定搜索关键词")
 		fmt.Fprintln(os.Stderr, "使用 -h 查看帮助")
 		os.Exit(1)
@@ -90,13 +143,21 @@ func main() {
 		Page:    *page,
 		Limit:   *limit,
 		Timeout: time.Duration(*timeout) * time.Second,
+
+		MinResults: *minResults,
+		Deadline:   time.Duration(*deadline) * time.Millisecond,
+		HedgeAfter: time.Duration(*hedge) * time.Millisecond,
 	}
 
 	// 执行搜索
 	ctx := context.Background()
 
 	// 始终打印基本日志到 Stderr
-	fmt.Fprintf(os.Stderr, "🚀 开始搜索: 关键词=%q, 页码=%d, 限制=%d\n", *keyword, *page, *limit)
+	if browseMode != "" {
+		fmt.Fprintf(os.Stderr, "🚀 开始浏览: 模式=%s, 页码=%d, 限制=%d\n", browseMode, *page, *limit)
+	} else {
+		fmt.Fprintf(os.Stderr, "🚀 开始搜索: 关键词=%q, 页码=%d, 限制=%d\n", *keyword, *page, *limit)
+	}
 	if len(sourceIDs) > 0 {
 		fmt.Fprintf(os.Stderr, "🎯 指定源: %v\n", sourceIDs)
 	} else {
@@ -114,10 +175,12 @@ func main() {
 
 	var result core.SearchResult
 	start := time.Now()
-	if len(sourceIDs) > 0 {
-		result = registry.SearchSources(ctx, *keyword, sourceIDs, opts)
+	if browseMode != "" {
+		result = registry.Browse(ctx, browseMode, sourceIDs, opts)
+	} else if *stream {
+		result = streamSearch(ctx, os.Stdout, registry, *keyword, sourceIDs, opts, *outputJSON)
 	} else {
-		result = registry.SearchAll(ctx, *keyword, opts)
+		result = registry.SearchSources(ctx, *keyword, sourceIDs, opts)
 	}
 	elapsed := time.Since(start)
 
@@ -131,15 +194,70 @@ func main() {
 	duration := time.Since(startTime)
 
 	fmt.Fprintf(os.Stderr, "✅ 搜索结束: 耗时 %v, 找到 %d 个结果\n", duration, len(result.Memes))
+	if *verbose && result.TraceID != "" {
+		fmt.Fprintf(os.Stderr, "🔍 TraceID: %s\n", result.TraceID)
+	}
 
-	// 输出结果
-	if *outputJSON {
+	// 输出结果 (流式模式下表情包与汇总已由 streamSearch 输出)
+	switch {
+	case *stream && browseMode == "":
+	case *outputJSON:
 		printJSON(result)
-	} else {
+	default:
 		printPretty(result, *verbose)
 	}
 }
 
+// streamSummary 流式 JSON 输出的最后一行，表情包已在各源的行中输出，这里只保留汇总字段
+type streamSummary struct {
+	Summary bool `json:"summary"` // 固定为 true，用于区分各源的结果行
+	core.SearchResult
+	Memes []core.Meme `json:"memes,omitempty"` // 覆盖 SearchResult.Memes，始终为空
+}
+
+// streamSearch 流式搜索，每个源完成时立即输出，最后输出汇总
+// JSON 模式下整体为 NDJSON：每个源一行，最后一行为 streamSummary
+func streamSearch(ctx context.Context, w io.Writer, registry *core.Registry, keyword string, sourceIDs []string, opts core.SearchOptions, asJSON bool) core.SearchResult {
+	result := registry.SearchStream(ctx, keyword, sourceIDs, opts, func(r core.SourceResult) {
+		printSourceResult(w, r, asJSON)
+	})
+	if !asJSON {
+		printSummary(w, result)
+		return result
+	}
+	data, err := json.Marshal(streamSummary{Summary: true, SearchResult: result})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "JSON 序列化失败: %v\n", err)
+		return result
+	}
+	fmt.Fprintln(w, string(data))
+	return result
+}
+
+// printSourceResult 流式输出单个源的结果，JSON 模式下每行一个 JSON 对象
+func printSourceResult(w io.Writer, r core.SourceResult, asJSON bool) {
+	if asJSON {
+		data, err := json.Marshal(r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "JSON 序列化失败: %v\n", err)
+			return
+		}
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	if r.Err != nil {
+		fmt.Fprintf(w, "🔴 [%d/%d] %s 失败: %v\n\n", r.Done, r.Total, r.SourceID, r.Err)
+		return
+	}
+
+	fmt.Fprintf(w, "🟢 [%d/%d] %s 返回 %d 个结果 (%dms)\n", r.Done, r.Total, r.SourceID, len(r.Memes), r.DurationMs)
+	for _, meme := range r.Memes {
+		fmt.Fprintf(w, "    %s  %s\n", meme.Title, meme.URL)
+	}
+	fmt.Fprintln(w)
+}
+
 func printSources(registry *core.Registry, asJSON bool) {
 	infos := sources.GetAllSourceInfo(registry)
 
@@ -150,16 +268,28 @@ func printSources(registry *core.Registry, asJSON bool) {
 	}
 
 	fmt.Println("📦 可用的表情包源:")
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("%-12s %-10s %-30s %s\n", "ID", "名称", "描述", "认证")
-	fmt.Println(strings.Repeat("-", 60))
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("%-12s %-10s %-30s %-8s %-8s %s\n", "ID", "名称", "描述", "认证", "翻页", "浏览")
+	fmt.Println(strings.Repeat("-", 80))
 
 	for _, info := range infos {
 		auth := "❌"
 		if info.RequiresAuth {
 			auth = "✅ 需要"
 		}
-		fmt.Printf("%-12s %-10s %-30s %s\n", info.ID, info.Name, info.Description, auth)
+		paging := "❌"
+		if info.Capabilities.SupportsPagination {
+			paging = "✅"
+		}
+		browse := "-"
+		if len(info.Capabilities.BrowseModes) > 0 {
+			modes := make([]string, 0, len(info.Capabilities.BrowseModes))
+			for _, m := range info.Capabilities.BrowseModes {
+				modes = append(modes, string(m))
+			}
+			browse = strings.Join(modes, ",")
+		}
+		fmt.Printf("%-12s %-10s %-30s %-8s %-8s %s\n", info.ID, info.Name, info.Description, auth, paging, browse)
 	}
 }
 
@@ -172,28 +302,39 @@ func printJSON(result core.SearchResult) {
 	fmt.Println(string(data))
 }
 
-func printPretty(result core.SearchResult, verbose bool) {
-	// 打印统计信息
-	fmt.Printf("✅ 搜索完成! 耗时: %dms\n", result.DurationMs)
-	fmt.Printf("📊 共找到 %d 个表情包\n", result.Total)
+// printSummary 打印统计信息，没有结果时返回 false
+func printSummary(w io.Writer, result core.SearchResult) bool {
+	fmt.Fprintf(w, "✅ 搜索完成! 耗时: %dms\n", result.DurationMs)
+	fmt.Fprintf(w, "📊 共找到 %d 个表情包\n", result.Total)
 
 	if len(result.Sources) > 0 {
-		fmt.Printf("🟢 成功的源: %s\n", strings.Join(result.Sources, ", "))
+		fmt.Fprintf(w, "🟢 成功的源: %s\n", strings.Join(result.Sources, ", "))
 	}
 
 	if len(result.Errors) > 0 {
-		fmt.Printf("🔴 失败的源: ")
+		fmt.Fprintf(w, "🔴 失败的源: ")
 		errStrs := []string{}
 		for id, err := range result.Errors {
 			errStrs = append(errStrs, fmt.Sprintf("%s(%s)", id, err))
 		}
-		fmt.Println(strings.Join(errStrs, ", "))
+		fmt.Fprintln(w, strings.Join(errStrs, ", "))
+	}
+
+	if len(result.Cancelled) > 0 {
+		fmt.Fprintf(w, "⏹️  已取消的源: %s\n", strings.Join(result.Cancelled, ", "))
 	}
 
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	if len(result.Memes) == 0 {
-		fmt.Println("😢 没有找到相关表情包")
+		fmt.Fprintln(w, "😢 没有找到相关表情包")
+		return false
+	}
+	return true
+}
+
+func printPretty(result core.SearchResult, verbose bool) {
+	if !printSummary(os.Stdout, result) {
 		return
 	}
 
@@ -206,6 +347,12 @@ func printPretty(result core.SearchResult, verbose bool) {
 		fmt.Printf("    📦 来源: %s\n", meme.Platform)
 		if verbose {
 			fmt.Printf("    🔗 URL: %s\n", meme.URL)
+			if meme.Thumbnail != "" && meme.Thumbnail != meme.URL {
+				fmt.Printf("    🖼  缩略图: %s\n", meme.Thumbnail)
+			}
+			for _, alt := range meme.Alternates {
+				fmt.Printf("    ↪  备用: %s\n", alt)
+			}
 			if meme.Format != "" {
 				fmt.Printf("    📄 格式: %s\n", meme.Format)
 			}
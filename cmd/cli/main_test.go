@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shadow/meme/internal/core"
+)
+
+// fakeSource 返回固定结果或错误的源
+type fakeSource struct {
+	id    string
+	memes int
+	err   error
+}
+
+func (s fakeSource) ID() string                      { return s.id }
+func (s fakeSource) Name() string                    { return s.id }
+func (s fakeSource) Description() string             { return "" }
+func (s fakeSource) RequiresAuth() bool              { return false }
+func (s fakeSource) Capabilities() core.Capabilities { return core.Capabilities{HonorsLimit: true} }
+func (s fakeSource) Search(context.Context, string, core.SearchOptions) ([]core.Meme, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	memes := make([]core.Meme, s.memes)
+	for i := range memes {
+		memes[i] = core.Meme{Title: fmt.Sprintf("%s-%d", s.id, i), URL: fmt.Sprintf("https://img.example.com/%s/%d.gif", s.id, i), Platform: s.id}
+	}
+	return memes, nil
+}
+
+func newStreamRegistry() *core.Registry {
+	registry := core.NewRegistry()
+	registry.Register(fakeSource{id: "pdan", memes: 2})
+	registry.Register(fakeSource{id: "qudoutu", memes: 1})
+	registry.Register(fakeSource{id: "broken", err: errors.New("unexpected status code: 503")})
+	return registry
+}
+
+func TestStreamSearchJSON(t *testing.T) {
+	var out bytes.Buffer
+	streamSearch(context.Background(), &out, newStreamRegistry(), "猫", []string{"pdan", "qudoutu", "broken"}, core.DefaultSearchOptions(), true)
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 3 source lines and 1 summary:\n%s", len(lines), out.String())
+	}
+
+	var ids []string
+	for i, line := range lines[:3] {
+		var r core.SourceResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", i+1, err, line)
+		}
+		if r.Done != i+1 || r.Total != 3 {
+			t.Errorf("line %d progress = %d/%d, want %d/3", i+1, r.Done, r.Total, i+1)
+		}
+		switch r.SourceID {
+		case "broken":
+			if r.Error != "unexpected status code: 503" || len(r.Memes) != 0 {
+				t.Errorf("broken line = %s, want error only", line)
+			}
+		case "pdan", "qudoutu":
+			if r.Error != "" || len(r.Memes) == 0 {
+				t.Errorf("%s line = %s, want memes", r.SourceID, line)
+			}
+		}
+		ids = append(ids, r.SourceID)
+	}
+	sort.Strings(ids)
+	if got := strings.Join(ids, ","); got != "broken,pdan,qudoutu" {
+		t.Errorf("streamed sources = %s, want each source once", got)
+	}
+
+	var summary map[string]any
+	if err := json.Unmarshal([]byte(lines[3]), &summary); err != nil {
+		t.Fatalf("summary is not a JSON object: %v\n%s", err, lines[3])
+	}
+	if summary["summary"] != true || summary["total"] != float64(3) {
+		t.Errorf("summary = %s, want summary with total 3", lines[3])
+	}
+	if _, ok := summary["memes"]; ok {
+		t.Errorf("summary repeats memes already streamed: %s", lines[3])
+	}
+	if errs, _ := summary["errors"].(map[string]any); errs["broken"] != "unexpected status code: 503" {
+		t.Errorf("summary errors = %v, want broken", summary["errors"])
+	}
+}
+
+func TestStreamSearchText(t *testing.T) {
+	var out bytes.Buffer
+	result := streamSearch(context.Background(), &out, newStreamRegistry(), "猫", nil, core.DefaultSearchOptions(), false)
+	if result.Total != 3 {
+		t.Errorf("Total = %d, want 3", result.Total)
+	}
+
+	text := out.String()
+	for i := 1; i <= 3; i++ {
+		if !strings.Contains(text, fmt.Sprintf("[%d/3]", i)) {
+			t.Errorf("output missing progress [%d/3]:\n%s", i, text)
+		}
+	}
+	for _, want := range []string{"pdan 返回 2 个结果", "qudoutu 返回 1 个结果", "broken 失败: unexpected status code: 503", "共找到 3 个表情包"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+}
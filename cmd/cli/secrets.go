@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shadow/meme/internal/secrets"
+)
+
+// runSecrets 管理加密存储: list / set <name> / delete <name>
+// set 从标准输入读取值，避免秘密出现在命令行历史与进程列表中
+func runSecrets(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, `用法:
+  meme-cli secrets list
+  meme-cli secrets set <名称> < 文件     # 从标准输入读取值
+  meme-cli secrets delete <名称>
+
+需要设置环境变量 `+secrets.StoreKeyEnv+` 作为加密口令，存储路径可通过 `+secrets.StorePathEnv+` 指定`)
+		return 2
+	}
+
+	store, err := secrets.OpenStore(secrets.DefaultStorePath(), os.Getenv(secrets.StoreKeyEnv))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开加密存储失败: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "list":
+		for _, name := range store.Names() {
+			value, _ := store.Get(name)
+			fmt.Printf("%-20s %s\n", name, value)
+		}
+		return 0
+
+	case "set":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "错误: 请指定名称，如 meme-cli secrets set DOUYIN_COOKIE")
+			return 2
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取标准输入失败: %v\n", err)
+			return 1
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			fmt.Fprintln(os.Stderr, "错误: 值为空")
+			return 1
+		}
+		store.Set(args[1], value)
+		if err := store.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "保存失败: %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ 已保存 %s (%s) 到 %s\n", args[1], secrets.Mask(value), store.Path())
+		return 0
+
+	case "delete":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "错误: 请指定名称")
+			return 2
+		}
+		if !store.Delete(args[1]) {
+			fmt.Fprintf(os.Stderr, "未找到 %s\n", args[1])
+			return 1
+		}
+		if err := store.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "保存失败: %v\n", err)
+			return 1
+		}
+		fmt.Printf("🗑️  已删除 %s\n", args[1])
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "未知的 secrets 子命令: %s\n", args[0])
+	return 2
+}
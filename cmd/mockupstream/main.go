@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/shadow/meme/internal/mockupstream"
+	"github.com/shadow/meme/internal/utils"
+)
+
+var logger = utils.Logger("mockupstream")
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8089", "监听地址")
+	latency := flag.String("latency", "", "响应延迟，如 200ms 或 pdan=2s,*=100ms")
+	faults := flag.String("fail", "", "注入故障，如 doutula=503,pdan=hang,sougou=malformed@0.5,douyin=logout")
+	total := flag.Int("total", 30, "每个关键词的结果总数")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Mock Upstream - 模拟各表情包站点的本地服务，用于端到端测试
+
+用法:
+  mockupstream [选项]
+  PDAN_BASE_URL=http://127.0.0.1:8089 meme-cli -k 猫 -s pdan
+
+站点: %v
+故障类型: 状态码 (如 503)、hang (不响应)、malformed (响应体无法解析)、logout (抖音未登录)
+
+选项:
+`, mockupstream.Sites)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	opts := mockupstream.Options{Total: *total}
+	var err error
+	if opts.Latency, err = mockupstream.ParseLatency(*latency); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if opts.Faults, err = mockupstream.ParseFaults(*faults); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logger.Info("listening", "addr", *addr, "latency", *latency, "fail", *faults)
+	if err := http.ListenAndServe(*addr, mockupstream.New(opts)); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
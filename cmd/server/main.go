@@ -1,42 +1,70 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
+	"net/http"
 	"os"
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/metrics"
 	"github.com/shadow/meme/internal/sources"
 	"github.com/shadow/meme/internal/tools"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
 )
 
+var logger = utils.Logger("server")
+
 func main() {
+	// HTTP 模式监听地址，为空时使用 Stdio
+	httpAddr := flag.String("http", os.Getenv("MCP_HTTP_ADDR"), "以 HTTP (SSE) 模式监听的地址，如 :8080，同时提供 /metrics")
+	flag.Parse()
+
+	// 链路追踪 (OTEL_EXPORTER_OTLP_ENDPOINT / MEME_TRACE_FILE)
+	tracer, err := tracing.Setup(tracing.ConfigFromEnv("meme-server"))
+	if err != nil {
+		logger.Error("tracing setup failed", "error", err)
+		os.Exit(1)
+	}
+	defer tracer.Shutdown(context.Background())
+
 	// 创建注册中心
 	registry := core.NewRegistry()
 
-	// 从环境变量读取配置
-	config := &sources.Config{
-		DouyinCookie:  os.Getenv("DOUYIN_COOKIE"),
-		ImageProxyURL: os.Getenv("IMAGE_PROXY_URL"),
+	// 读取配置 (Cookie 可来自环境变量、文件或加密存储)
+	config, err := sources.LoadConfig()
+	if err != nil {
+		logger.Error("load config failed", "error", err)
+		os.Exit(1)
 	}
 
 	// 注册所有源
 	sources.RegisterAllSources(registry, config)
 
 	// 创建 MCP Server
-	s := server.NewMCPServer(
-		"meme-server",
-		"1.0.0",
-		server.WithToolCapabilities(true),
-	)
+	s := tools.NewServer(registry, core.DefaultCache)
 
-	// 注册 Tools
-	s.AddTool(tools.NewSearchMemeTool(registry), tools.HandleSearchMeme(registry))
-	s.AddTool(tools.NewListSourcesTool(), tools.HandleListSources(registry))
+	// 启动 HTTP 服务 (SSE + /metrics)
+	if *httpAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default.Handler())
+		mux.Handle("/", server.NewSSEServer(s))
+
+		logger.Info("listening", "addr", *httpAddr, "sse", "/sse", "metrics", "/metrics")
+		if err := http.ListenAndServe(*httpAddr, mux); err != nil {
+			logger.Error("server stopped", "error", err)
+			tracer.Shutdown(context.Background())
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 启动 Stdio 服务
 	if err := server.ServeStdio(s); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		logger.Error("server stopped", "error", err)
+		tracer.Shutdown(context.Background())
 		os.Exit(1)
 	}
 }
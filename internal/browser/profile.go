@@ -0,0 +1,292 @@
+package browser
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Family 浏览器内核系列，决定发送哪些请求头 (如只有 Chromium 发送 Client Hints)
+type Family string
+
+const (
+	FamilyChromium Family = "chromium"
+	FamilyFirefox  Family = "firefox"
+	FamilySafari   Family = "safari"
+)
+
+// Kind 请求类型，对应浏览器中的页面导航、脚本请求与图片加载
+type Kind string
+
+const (
+	KindDocument Kind = "document" // 页面导航 (fetchHTML)
+	KindAPI      Kind = "api"      // XHR / fetch 请求 JSON 接口
+	KindImage    Kind = "image"    // <img> 加载图片
+)
+
+// Header 一个请求头
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Profile 一组相互一致的浏览器请求头：User-Agent、Client Hints 与 Accept 系列
+type Profile struct {
+	Name           string `json:"name"`
+	Family         Family `json:"family"`
+	UserAgent      string `json:"user_agent"`
+	Brands         string `json:"sec_ch_ua,omitempty"`          // sec-ch-ua，仅 Chromium
+	Platform       string `json:"sec_ch_ua_platform,omitempty"` // sec-ch-ua-platform，仅 Chromium
+	AcceptLanguage string `json:"accept_language"`
+}
+
+// 内置配置，版本号与 Client Hints 保持一致
+var builtin = []*Profile{
+	{
+		Name:           "chrome-mac",
+		Family:         FamilyChromium,
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+		Brands:         `"Not;A=Brand";v="99", "Google Chrome";v="139", "Chromium";v="139"`,
+		Platform:       `"macOS"`,
+		AcceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8",
+	},
+	{
+		Name:           "chrome-win",
+		Family:         FamilyChromium,
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+		Brands:         `"Not;A=Brand";v="99", "Google Chrome";v="139", "Chromium";v="139"`,
+		Platform:       `"Windows"`,
+		AcceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8",
+	},
+	{
+		Name:           "edge-win",
+		Family:         FamilyChromium,
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0",
+		Brands:         `"Not;A=Brand";v="99", "Microsoft Edge";v="139", "Chromium";v="139"`,
+		Platform:       `"Windows"`,
+		AcceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8,en-GB;q=0.7,en-US;q=0.6",
+	},
+	{
+		Name:           "firefox-win",
+		Family:         FamilyFirefox,
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:141.0) Gecko/20100101 Firefox/141.0",
+		AcceptLanguage: "zh-CN,zh;q=0.8,zh-TW;q=0.7,zh-HK;q=0.5,en-US;q=0.3,en;q=0.2",
+	},
+	{
+		Name:           "safari-mac",
+		Family:         FamilySafari,
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.5 Safari/605.1.15",
+		AcceptLanguage: "zh-CN,zh-Hans;q=0.9",
+	},
+}
+
+// DefaultName 未配置时使用的配置
+const DefaultName = "chrome-mac"
+
+// Default 返回默认配置
+func Default() *Profile {
+	p, _ := Lookup(DefaultName)
+	return p
+}
+
+// Lookup 按名称查找内置配置
+func Lookup(name string) (*Profile, bool) {
+	for _, p := range builtin {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Names 返回所有内置配置名称
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for _, p := range builtin {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve 按名称列表查找配置，"all" 表示全部内置配置
+func Resolve(names []string) ([]*Profile, error) {
+	var profiles []*Profile
+	for _, name := range names {
+		if name == "all" {
+			profiles = append(profiles, builtin...)
+			continue
+		}
+		p, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown browser profile %q (available: %s)", name, strings.Join(Names(), ", "))
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// Headers 返回该类型请求的请求头；写入 http.Header 后不保留顺序
+// Accept-Encoding 由 HTTP 客户端根据实际支持的解码方式设置，这里不包含
+func (p *Profile) Headers(kind Kind) []Header {
+	var headers []Header
+	add := func(name, value string) {
+		headers = append(headers, Header{Name: name, Value: value})
+	}
+	hints := func() {
+		if p.Family == FamilyChromium {
+			add("sec-ch-ua", p.Brands)
+			add("sec-ch-ua-mobile", "?0")
+			add("sec-ch-ua-platform", p.Platform)
+		}
+	}
+
+	switch kind {
+	case KindDocument:
+		if p.Family == FamilyChromium {
+			hints()
+			add("Upgrade-Insecure-Requests", "1")
+			add("User-Agent", p.UserAgent)
+			add("Accept", p.accept(kind))
+		} else {
+			add("User-Agent", p.UserAgent)
+			add("Accept", p.accept(kind))
+			add("Accept-Language", p.AcceptLanguage)
+			add("Upgrade-Insecure-Requests", "1")
+		}
+		add("Sec-Fetch-Site", "none")
+		add("Sec-Fetch-Mode", "navigate")
+		add("Sec-Fetch-User", "?1")
+		add("Sec-Fetch-Dest", "document")
+	case KindAPI, KindImage:
+		if p.Family == FamilyChromium {
+			hints()
+		}
+		add("User-Agent", p.UserAgent)
+		add("Accept", p.accept(kind))
+		if p.Family != FamilyChromium {
+			add("Accept-Language", p.AcceptLanguage)
+		}
+		if kind == KindAPI {
+			add("Sec-Fetch-Site", "same-origin")
+			add("Sec-Fetch-Mode", "cors")
+			add("Sec-Fetch-Dest", "empty")
+		} else {
+			add("Sec-Fetch-Site", "cross-site")
+			add("Sec-Fetch-Mode", "no-cors")
+			add("Sec-Fetch-Dest", "image")
+		}
+	}
+	if p.Family == FamilyChromium {
+		add("Accept-Language", p.AcceptLanguage)
+	}
+	return headers
+}
+
+// Apply 将该类型请求的请求头写入 h (覆盖同名请求头)
+func (p *Profile) Apply(h http.Header, kind Kind) {
+	for _, header := range p.Headers(kind) {
+		h.Set(header.Name, header.Value)
+	}
+}
+
+func (p *Profile) accept(kind Kind) string {
+	switch {
+	case kind == KindAPI:
+		return "application/json, text/plain, */*"
+	case kind == KindImage && p.Family == FamilyChromium:
+		return "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	case kind == KindImage && p.Family == FamilyFirefox:
+		return "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	case kind == KindImage:
+		return "image/webp,image/avif,image/jxl,image/heic,image/heic-sequence,video/*;q=0.8,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+	case p.Family == FamilyChromium:
+		return "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+	default:
+		return "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	}
+}
+
+// ============ 轮换 ============
+
+// Rotation 多个配置之间的轮换策略
+type Rotation string
+
+const (
+	// RotationFixed 始终使用第一个配置
+	RotationFixed Rotation = "fixed"
+	// RotationSticky 启动时随机选定一个配置，之后不再变化
+	RotationSticky Rotation = "sticky"
+	// RotationRoundRobin 每次请求依次使用下一个配置
+	RotationRoundRobin Rotation = "round_robin"
+	// RotationRandom 每次请求随机选择一个配置
+	RotationRandom Rotation = "random"
+)
+
+// ParseRotation 解析轮换策略，空串为 fixed
+func ParseRotation(s string) (Rotation, error) {
+	switch r := Rotation(strings.TrimSpace(s)); r {
+	case "":
+		return RotationFixed, nil
+	case RotationFixed, RotationSticky, RotationRoundRobin, RotationRandom:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid browser rotation %q (want fixed, sticky, round_robin or random)", s)
+}
+
+// Rotator 按策略从一组配置中选取本次请求使用的配置，可并发使用
+type Rotator struct {
+	profiles []*Profile
+	rotation Rotation
+
+	mu   sync.Mutex
+	next int
+	rand *rand.Rand
+}
+
+// NewRotator 创建轮换器，profiles 为空时使用默认配置
+func NewRotator(profiles []*Profile, rotation Rotation) *Rotator {
+	if len(profiles) == 0 {
+		profiles = []*Profile{Default()}
+	}
+	r := &Rotator{
+		profiles: profiles,
+		rotation: rotation,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if rotation == RotationSticky {
+		r.next = r.rand.Intn(len(profiles))
+	}
+	return r
+}
+
+// Next 返回本次请求使用的配置
+func (r *Rotator) Next() *Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.rotation {
+	case RotationRoundRobin:
+		p := r.profiles[r.next%len(r.profiles)]
+		r.next++
+		return p
+	case RotationRandom:
+		return r.profiles[r.rand.Intn(len(r.profiles))]
+	case RotationSticky:
+		return r.profiles[r.next]
+	}
+	return r.profiles[0]
+}
+
+// Profiles 返回参与轮换的配置名称
+func (r *Rotator) Profiles() []string {
+	names := make([]string, len(r.profiles))
+	for i, p := range r.profiles {
+		names[i] = p.Name
+	}
+	return names
+}
@@ -0,0 +1,80 @@
+package browser
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProfilesConsistent(t *testing.T) {
+	for _, name := range Names() {
+		p, _ := Lookup(name)
+		for _, kind := range []Kind{KindDocument, KindAPI, KindImage} {
+			h := http.Header{}
+			p.Apply(h, kind)
+
+			if h.Get("User-Agent") != p.UserAgent || h.Get("Accept") == "" || h.Get("Accept-Language") == "" {
+				t.Errorf("%s/%s: missing basic headers: %v", name, kind, h)
+			}
+			if h.Get("Accept-Encoding") != "" {
+				t.Errorf("%s/%s: Accept-Encoding should be left to the client", name, kind)
+			}
+			hasHints := h.Get("sec-ch-ua") != ""
+			if hasHints != (p.Family == FamilyChromium) {
+				t.Errorf("%s/%s: sec-ch-ua present = %v for family %s", name, kind, hasHints, p.Family)
+			}
+			if hasHints && !strings.Contains(p.UserAgent, "Chrome/139") {
+				t.Errorf("%s: client hints version does not match user agent", name)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	profiles, err := Resolve([]string{"all"})
+	if err != nil || len(profiles) != len(Names()) {
+		t.Errorf("Resolve(all) = %d profiles, %v", len(profiles), err)
+	}
+	if _, err := Resolve([]string{"chrome-mac", "netscape"}); err == nil {
+		t.Error("want error for unknown profile")
+	}
+	if _, err := ParseRotation("shuffle"); err == nil {
+		t.Error("want error for unknown rotation")
+	}
+}
+
+func TestRotator(t *testing.T) {
+	profiles, _ := Resolve([]string{"chrome-win", "firefox-win", "safari-mac"})
+
+	next := func(r *Rotator, n int) []string {
+		var names []string
+		for i := 0; i < n; i++ {
+			names = append(names, r.Next().Name)
+		}
+		return names
+	}
+
+	if got := strings.Join(next(NewRotator(profiles, RotationFixed), 3), ","); got != "chrome-win,chrome-win,chrome-win" {
+		t.Errorf("fixed = %s", got)
+	}
+	if got := strings.Join(next(NewRotator(profiles, RotationRoundRobin), 4), ","); got != "chrome-win,firefox-win,safari-mac,chrome-win" {
+		t.Errorf("round_robin = %s", got)
+	}
+	sticky := next(NewRotator(profiles, RotationSticky), 5)
+	for _, name := range sticky {
+		if name != sticky[0] {
+			t.Errorf("sticky changed profile: %v", sticky)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, name := range next(NewRotator(profiles, RotationRandom), 200) {
+		seen[name] = true
+	}
+	if len(seen) != len(profiles) {
+		t.Errorf("random used %d of %d profiles", len(seen), len(profiles))
+	}
+
+	if got := NewRotator(nil, RotationFixed).Next(); got != Default() {
+		t.Errorf("empty rotator = %s, want default", got.Name)
+	}
+}
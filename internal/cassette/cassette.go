@@ -0,0 +1,286 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/shadow/meme/internal/utils"
+)
+
+// Mode 回放或录制
+type Mode string
+
+const (
+	// ModeReplay 只从磁带回放，找不到匹配的记录时返回错误，不访问网络
+	ModeReplay Mode = "replay"
+	// ModeRecord 请求真实网络并写入磁带，用于刷新测试数据
+	ModeRecord Mode = "record"
+)
+
+// ModeFromEnv 读取 MEME_CASSETTE_MODE，未设置时为回放
+func ModeFromEnv() Mode {
+	if strings.EqualFold(os.Getenv("MEME_CASSETTE_MODE"), string(ModeRecord)) {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Interaction 一次请求与对应的响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request 录制的请求，只保存用于匹配的方法与 URL
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response 录制的响应，文本以原文保存，二进制 (如 gzip、图片) 以 Base64 保存
+type Response struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodyBase64 string            `json:"body_base64,omitempty"`
+}
+
+// Cassette 一个磁带文件中的全部交互
+type Cassette struct {
+	Origin       string        `json:"origin,omitempty"` // 录制来源，如 live (真实网站) 或 mockupstream (本地模拟上游)
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load 读取磁带文件
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette failed: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decode cassette failed: %w", err)
+	}
+	return &c, nil
+}
+
+// Save 写入磁带文件，目录不存在时自动创建
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cassette failed: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cassette dir failed: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write cassette failed: %w", err)
+	}
+	return nil
+}
+
+// Transport 按磁带回放或录制 HTTP 请求
+// 同一请求出现多次时按录制顺序依次返回，用完后重复返回最后一条
+type Transport struct {
+	path   string
+	mode   Mode
+	real   http.RoundTripper
+	ignore map[string]bool
+	origin string
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     map[string]int
+}
+
+// Option 配置 Transport
+type Option func(*Transport)
+
+// WithIgnoredParams 匹配请求时忽略指定的查询参数 (如时间戳、签名等每次都会变化的参数)
+func WithIgnoredParams(names ...string) Option {
+	return func(t *Transport) {
+		for _, name := range names {
+			t.ignore[name] = true
+		}
+	}
+}
+
+// WithRealTransport 指定录制模式下实际发送请求的 Transport，默认 http.DefaultTransport
+func WithRealTransport(rt http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.real = rt
+	}
+}
+
+// WithOrigin 录制模式下在磁带中注明响应来源，回放时可通过 Origin 读取
+func WithOrigin(origin string) Option {
+	return func(t *Transport) {
+		t.origin = origin
+	}
+}
+
+// New 创建磁带 Transport；回放模式下读取 path，录制模式下从空磁带开始，调用 Save 写入 path
+func New(path string, mode Mode, opts ...Option) (*Transport, error) {
+	t := &Transport{
+		path:   path,
+		mode:   mode,
+		real:   http.DefaultTransport,
+		ignore: make(map[string]bool),
+		used:   make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if mode == ModeRecord {
+		t.cassette = &Cassette{Origin: t.origin}
+		return t, nil
+	}
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	t.cassette = c
+	return t, nil
+}
+
+// Save 录制模式下写入磁带文件，回放模式下不做任何事
+func (t *Transport) Save() error {
+	if t.mode != ModeRecord {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Save(t.path)
+}
+
+// Origin 磁带的录制来源
+func (t *Transport) Origin() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Origin
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == ModeRecord {
+		return t.record(req)
+	}
+	return t.replay(req)
+}
+
+// matchKey 请求的匹配键：方法 + 去掉忽略参数并排序后的 URL
+func (t *Transport) matchKey(method, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+	query := u.Query()
+	for name := range t.ignore {
+		query.Del(name)
+	}
+	u.RawQuery = query.Encode() // Encode 按键排序
+	u.Fragment = ""
+	return method + " " + u.String()
+}
+
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	// 录制时 URL 已脱敏，回放时按同样规则脱敏后再匹配
+	key := t.matchKey(req.Method, utils.RedactURL(req.URL.String()))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var matches []int
+	for i, in := range t.cassette.Interactions {
+		if t.matchKey(in.Request.Method, in.Request.URL) == key {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, req.URL)
+	}
+
+	n := t.used[key]
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	t.used[key]++
+
+	return t.cassette.Interactions[matches[n]].Response.toHTTP(req)
+}
+
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
+	}
+
+	recorded := Response{Status: resp.StatusCode, Headers: make(map[string]string)}
+	for name := range resp.Header {
+		// Cookie 等敏感响应头不写入磁带
+		if utils.IsSensitiveKey(name) {
+			continue
+		}
+		recorded.Headers[name] = resp.Header.Get(name)
+	}
+	if utf8.Valid(body) {
+		recorded.Body = string(body)
+	} else {
+		recorded.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+
+	// 请求 URL 中的敏感参数 (如 msToken) 脱敏后保存
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: utils.RedactURL(req.URL.String())},
+		Response: recorded,
+	})
+	t.mu.Unlock()
+
+	return recorded.toHTTP(req)
+}
+
+// toHTTP 还原为 http.Response
+func (r Response) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.BodyBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(r.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: decode body failed: %w", err)
+		}
+		body = decoded
+	}
+
+	header := make(http.Header, len(r.Headers))
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shadow/meme/internal/metrics"
+)
+
+// CachedSearch 缓存的一次搜索结果 (所有源的第一页)
+type CachedSearch struct {
+	Keyword  string       `json:"keyword"`
+	Limit    int          `json:"limit"` // 每个源的结果上限
+	Result   SearchResult `json:"result"`
+	CachedAt time.Time    `json:"cached_at"`
+}
+
+// ResultCache 最近搜索结果与已返回表情包的内存缓存
+// 用于通过稳定 URI 回查结果，超出容量时按写入顺序淘汰最旧的条目
+type ResultCache struct {
+	mu sync.RWMutex
+
+	maxSearches int
+	searches    map[string]CachedSearch
+	searchOrder []string
+
+	maxMemes  int
+	memes     map[string]Meme
+	memeOrder []string
+}
+
+// NewResultCache 创建缓存，maxSearches/maxMemes 分别为搜索结果与表情包的最大条数
+func NewResultCache(maxSearches, maxMemes int) *ResultCache {
+	return &ResultCache{
+		maxSearches: maxSearches,
+		searches:    make(map[string]CachedSearch),
+		maxMemes:    maxMemes,
+		memes:       make(map[string]Meme),
+	}
+}
+
+// MemeHash 返回表情包的稳定标识：源自带的标识，或与去重一致的 URL 键
+func MemeHash(meme Meme) string {
+	if meme.ID != "" {
+		return meme.ID
+	}
+	return ExtractURLKey(meme.URL)
+}
+
+// searchKey 规范化搜索关键词作为缓存键
+func searchKey(keyword string) string {
+	return strings.ToLower(strings.TrimSpace(keyword))
+}
+
+// Put 记录结果中的所有表情包，并按关键词缓存搜索结果
+// 只缓存未指定源的第一页：指定源或翻页的结果只是部分结果，不能覆盖关键词下的完整结果；
+// 已缓存的结果上限更大时同样保留原结果
+func (c *ResultCache) Put(keyword string, sourceIDs []string, opts SearchOptions, result SearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := searchKey(keyword)
+	old, cached := c.searches[key]
+	if len(sourceIDs) == 0 && opts.Page <= 1 && (!cached || opts.Limit >= old.Limit) {
+		if !cached {
+			c.searchOrder = append(c.searchOrder, key)
+		}
+		c.searches[key] = CachedSearch{
+			Keyword:  keyword,
+			Limit:    opts.Limit,
+			Result:   result,
+			CachedAt: time.Now(),
+		}
+		for len(c.searchOrder) > c.maxSearches {
+			delete(c.searches, c.searchOrder[0])
+			c.searchOrder = c.searchOrder[1:]
+		}
+	}
+
+	for _, meme := range result.Memes {
+		hash := MemeHash(meme)
+		if _, ok := c.memes[hash]; !ok {
+			c.memeOrder = append(c.memeOrder, hash)
+		}
+		c.memes[hash] = meme
+	}
+	for len(c.memeOrder) > c.maxMemes {
+		delete(c.memes, c.memeOrder[0])
+		c.memeOrder = c.memeOrder[1:]
+	}
+}
+
+// GetSearch 获取缓存的搜索结果
+func (c *ResultCache) GetSearch(keyword string) (CachedSearch, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	search, ok := c.searches[searchKey(keyword)]
+	recordCacheLookup("search", ok)
+	return search, ok
+}
+
+// ListSearches 按写入顺序列出缓存的搜索结果
+func (c *ResultCache) ListSearches() []CachedSearch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	searches := make([]CachedSearch, 0, len(c.searchOrder))
+	for _, key := range c.searchOrder {
+		searches = append(searches, c.searches[key])
+	}
+	return searches
+}
+
+// GetMeme 通过稳定标识获取曾经返回过的表情包
+func (c *ResultCache) GetMeme(hash string) (Meme, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	meme, ok := c.memes[hash]
+	recordCacheLookup("meme", ok)
+	return meme, ok
+}
+
+// recordCacheLookup 记录缓存命中情况
+func recordCacheLookup(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.CacheRequests.Inc(kind, result)
+}
+
+// 全局默认结果缓存
+var DefaultCache = NewResultCache(100, 5000)
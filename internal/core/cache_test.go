@@ -0,0 +1,42 @@
+package core
+
+import "testing"
+
+func TestResultCachePut(t *testing.T) {
+	full := SearchResult{Memes: []Meme{{URL: "https://a.example.com/1.gif"}, {URL: "https://b.example.com/2.gif"}}, Total: 2}
+	partial := SearchResult{Memes: []Meme{{URL: "https://a.example.com/3.gif"}}, Total: 1}
+
+	tests := []struct {
+		name      string
+		sourceIDs []string
+		opts      SearchOptions
+		wantTotal int // 之后读取 meme://search/猫 得到的结果数
+	}{
+		{"filtered by source", []string{"pdan"}, SearchOptions{Page: 1, Limit: 20}, 2},
+		{"second page", nil, SearchOptions{Page: 2, Limit: 20}, 2},
+		{"smaller limit", nil, SearchOptions{Page: 1, Limit: 5}, 2},
+		{"same query", nil, SearchOptions{Page: 1, Limit: 20}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewResultCache(10, 100)
+			cache.Put("猫", nil, SearchOptions{Page: 1, Limit: 20}, full)
+			cache.Put(" 猫 ", tt.sourceIDs, tt.opts, partial)
+
+			search, ok := cache.GetSearch("猫")
+			if !ok || search.Result.Total != tt.wantTotal {
+				t.Errorf("cached search = %+v, %v; want total %d", search, ok, tt.wantTotal)
+			}
+			// 部分结果中的表情包仍可通过 meme://image/{hash} 回查
+			if _, ok := cache.GetMeme(ExtractURLKey(partial.Memes[0].URL)); !ok {
+				t.Error("meme from partial result not cached")
+			}
+		})
+	}
+
+	cache := NewResultCache(10, 100)
+	cache.Put("狗", []string{"pdan"}, SearchOptions{Page: 1}, partial)
+	if _, ok := cache.GetSearch("狗"); ok || len(cache.ListSearches()) != 0 {
+		t.Error("filtered search should not be listed")
+	}
+}
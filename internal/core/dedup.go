@@ -1,20 +1,113 @@
 package core
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 )
 
 // 常见错误
 var (
-	ErrSourceNotFound = errors.New("source not found")
-	ErrEmptyKeyword   = errors.New("keyword cannot be empty")
-	ErrRequestFailed  = errors.New("request failed")
+	ErrSourceNotFound     = errors.New("source not found")
+	ErrEmptyKeyword       = errors.New("keyword cannot be empty")
+	ErrRequestFailed      = errors.New("request failed")
+	ErrBrowseNotSupported = errors.New("browse mode not supported by source")
+	ErrAuthRequired       = errors.New("authentication required")
+	ErrLayoutChanged      = errors.New("page layout changed")
 )
 
+// AuthError 源认证失败 (Cookie 缺失、过期或已被登出)，可用 errors.Is(err, ErrAuthRequired) 判断
+type AuthError struct {
+	Source string
+	Reason string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s auth failed: %s", e.Source, e.Reason)
+}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuthRequired
+}
+
+// ParseError 页面中找不到预期的结构 (站点改版导致选择器失效)，可用 errors.Is(err, ErrLayoutChanged) 判断
+// 页面明确显示没有结果时不会返回该错误
+type ParseError struct {
+	Source   string
+	URL      string
+	Selector string // 没有匹配到内容的选择器
+	Snippet  string // 页面中相关区域的 HTML 片段 (已截断)
+	DumpPath string // 保存的页面文件，未开启保存时为空
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("%s parse HTML failed: selector %q matched nothing on %s, page layout may have changed", e.Source, e.Selector, e.URL)
+	if e.DumpPath != "" {
+		msg += " (page saved to " + e.DumpPath + ")"
+	}
+	return msg
+}
+
+func (e *ParseError) Is(target error) bool {
+	return target == ErrLayoutChanged
+}
+
+// StatusError 上游返回了非预期的 HTTP 状态码
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.Code)
+}
+
+// DecodeError 响应内容无法解析 (JSON 格式错误、HTML 无法解析)
+type DecodeError struct {
+	Op  string // 如 "decode JSON"、"parse HTML"
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Op + " failed: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKind 将源返回的错误归类，用于统计与展示
+// 网络错误需包装 ErrRequestFailed，状态码与解析错误分别使用 StatusError 与 DecodeError
+func ErrorKind(err error) string {
+	var statusErr *StatusError
+	var decodeErr *DecodeError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrSourceNotFound):
+		return "not_found"
+	case errors.Is(err, ErrBrowseNotSupported):
+		return "unsupported"
+	case errors.Is(err, ErrAuthRequired):
+		return "auth"
+	case errors.Is(err, ErrLayoutChanged):
+		return "layout"
+	case errors.As(err, &statusErr):
+		return "http_status"
+	case errors.As(err, &decodeErr):
+		return "parse"
+	case errors.Is(err, ErrRequestFailed):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
 // 用于提取 URL 唯一标识的正则
 var (
 	// 抖音 URL 格式: /tos-cn-i-xxx/ID~...
@@ -33,7 +126,8 @@ func ExtractURLKey(url string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// DeduplicateMemes 对表情包列表去重，保持顺序
+// DeduplicateMemes 对表情包列表去重，保持顺序，并为没有标识的结果填充稳定标识
+// 源自带的标识 (如抖音贴纸ID) 予以保留
 func DeduplicateMemes(memes []Meme) []Meme {
 	seen := make(map[string]bool)
 	result := make([]Meme, 0, len(memes))
@@ -42,6 +136,9 @@ func DeduplicateMemes(memes []Meme) []Meme {
 		key := ExtractURLKey(meme.URL)
 		if !seen[key] {
 			seen[key] = true
+			if meme.ID == "" {
+				meme.ID = key
+			}
 			result = append(result, meme)
 		}
 	}
@@ -60,6 +157,22 @@ func NormalizeURL(url string) string {
 	return url
 }
 
+// CollectImageURLs 标准化候选地址，按顺序返回其中有效且不重复的图片 URL
+// 源可用第一个作为主地址，其余作为备用地址
+func CollectImageURLs(candidates ...string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range candidates {
+		u = NormalizeURL(u)
+		if seen[u] || !IsValidImageURL(u) {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
 // IsValidImageURL 检查是否是有效的图片 URL
 func IsValidImageURL(url string) bool {
 	if url == "" {
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 关键词类别权重，数值越大越优先
+const (
+	weightEmotion = 3 // 情绪词，表情包最主要的检索维度
+	weightScene   = 2 // 场景词，如加班、考试
+	weightSubject = 1 // 主体词，如猫、狗
+)
+
+// lexiconEntry 词典条目
+type lexiconEntry struct {
+	weight   int
+	keywords []string // 命中后用于搜索的关键词，首个为主关键词
+}
+
+// keywordLexicon 内置的中文情绪/场景词典
+// 键为文本中可能出现的词，值为对应的搜索关键词
+var keywordLexicon = map[string]lexiconEntry{
+	// 情绪 - 开心
+	"开心": {weightEmotion, []string{"开心"}},
+	"高兴": {weightEmotion, []string{"开心"}},
+	"快乐": {weightEmotion, []string{"开心"}},
+	"哈哈": {weightEmotion, []string{"哈哈哈"}},
+	"笑死": {weightEmotion, []string{"笑死", "哈哈哈"}},
+	"好笑": {weightEmotion, []string{"哈哈哈"}},
+	"兴奋": {weightEmotion, []string{"兴奋"}},
+	"激动": {weightEmotion, []string{"激动"}},
+	"期待": {weightEmotion, []string{"期待"}},
+	"得意": {weightEmotion, []string{"得意"}},
+	"骄傲": {weightEmotion, []string{"得意"}},
+	"害羞": {weightEmotion, []string{"害羞"}},
+	"喜欢": {weightEmotion, []string{"喜欢", "爱你"}},
+	"爱你": {weightEmotion, []string{"爱你"}},
+	"感动": {weightEmotion, []string{"感动"}},
+	// 情绪 - 难过
+	"难过":  {weightEmotion, []string{"难过"}},
+	"伤心":  {weightEmotion, []string{"伤心"}},
+	"哭":   {weightEmotion, []string{"哭"}},
+	"想哭":  {weightEmotion, []string{"哭"}},
+	"委屈":  {weightEmotion, []string{"委屈"}},
+	"心碎":  {weightEmotion, []string{"伤心"}},
+	"失落":  {weightEmotion, []string{"难过"}},
+	"孤独":  {weightEmotion, []string{"孤独"}},
+	"emo": {weightEmotion, []string{"emo"}},
+	// 情绪 - 生气
+	"生气": {weightEmotion, []string{"生气"}},
+	"愤怒": {weightEmotion, []string{"生气"}},
+	"气死": {weightEmotion, []string{"气死"}},
+	"烦":  {weightEmotion, []string{"烦"}},
+	"烦死": {weightEmotion, []string{"烦"}},
+	"讨厌": {weightEmotion, []string{"讨厌"}},
+	"嫌弃": {weightEmotion, []string{"嫌弃"}},
+	"鄙视": {weightEmotion, []string{"鄙视"}},
+	"打你": {weightEmotion, []string{"打你"}},
+	// 情绪 - 无语 / 尴尬 / 疑惑
+	"无语":  {weightEmotion, []string{"无语"}},
+	"尴尬":  {weightEmotion, []string{"尴尬"}},
+	"无奈":  {weightEmotion, []string{"无奈"}},
+	"疑惑":  {weightEmotion, []string{"疑惑", "问号"}},
+	"问号":  {weightEmotion, []string{"问号"}},
+	"为什么": {weightEmotion, []string{"疑惑"}},
+	"震惊":  {weightEmotion, []string{"震惊"}},
+	"惊讶":  {weightEmotion, []string{"震惊"}},
+	"害怕":  {weightEmotion, []string{"害怕"}},
+	"紧张":  {weightEmotion, []string{"紧张"}},
+	"焦虑":  {weightEmotion, []string{"焦虑"}},
+	"崩溃":  {weightEmotion, []string{"崩溃"}},
+	"绝望":  {weightEmotion, []string{"绝望"}},
+	"累":   {weightEmotion, []string{"累"}},
+	"好累":  {weightEmotion, []string{"累"}},
+	"困":   {weightEmotion, []string{"困"}},
+	"饿":   {weightEmotion, []string{"饿"}},
+	"摆烂":  {weightEmotion, []string{"摆烂"}},
+	"躺平":  {weightEmotion, []string{"躺平"}},
+	"裂开":  {weightEmotion, []string{"裂开"}},
+	"破防":  {weightEmotion, []string{"破防"}},
+	"麻了":  {weightEmotion, []string{"麻了"}},
+	// 社交回应
+	"谢谢":  {weightEmotion, []string{"谢谢"}},
+	"感谢":  {weightEmotion, []string{"谢谢"}},
+	"对不起": {weightEmotion, []string{"对不起"}},
+	"抱歉":  {weightEmotion, []string{"对不起"}},
+	"晚安":  {weightEmotion, []string{"晚安"}},
+	"早安":  {weightEmotion, []string{"早安"}},
+	"早上好": {weightEmotion, []string{"早安"}},
+	"再见":  {weightEmotion, []string{"再见"}},
+	"拜拜":  {weightEmotion, []string{"拜拜"}},
+	"好的":  {weightEmotion, []string{"好的"}},
+	"收到":  {weightEmotion, []string{"收到"}},
+	"加油":  {weightEmotion, []string{"加油"}},
+	"厉害":  {weightEmotion, []string{"厉害", "点赞"}},
+	"点赞":  {weightEmotion, []string{"点赞"}},
+	"666": {weightEmotion, []string{"666"}},
+	"冲鸭":  {weightEmotion, []string{"冲鸭"}},
+	"溜了":  {weightEmotion, []string{"溜了"}},
+	"求求":  {weightEmotion, []string{"求求你"}},
+	"拜托":  {weightEmotion, []string{"求求你"}},
+	// 场景
+	"加班": {weightScene, []string{"加班", "打工人"}},
+	"上班": {weightScene, []string{"上班", "打工人"}},
+	"下班": {weightScene, []string{"下班"}},
+	"打工": {weightScene, []string{"打工人"}},
+	"老板": {weightScene, []string{"老板"}},
+	"领导": {weightScene, []string{"老板"}},
+	"开会": {weightScene, []string{"开会"}},
+	"摸鱼": {weightScene, []string{"摸鱼"}},
+	"工资": {weightScene, []string{"发工资"}},
+	"没钱": {weightScene, []string{"穷"}},
+	"穷":  {weightScene, []string{"穷"}},
+	"考试": {weightScene, []string{"考试"}},
+	"作业": {weightScene, []string{"作业"}},
+	"学习": {weightScene, []string{"学习"}},
+	"放假": {weightScene, []string{"放假"}},
+	"周末": {weightScene, []string{"周末"}},
+	"周一": {weightScene, []string{"周一"}},
+	"熬夜": {weightScene, []string{"熬夜"}},
+	"失眠": {weightScene, []string{"失眠"}},
+	"睡觉": {weightScene, []string{"睡觉"}},
+	"迟到": {weightScene, []string{"迟到"}},
+	"减肥": {weightScene, []string{"减肥"}},
+	"吃饭": {weightScene, []string{"干饭"}},
+	"干饭": {weightScene, []string{"干饭"}},
+	"吃瓜": {weightScene, []string{"吃瓜"}},
+	"生日": {weightScene, []string{"生日快乐"}},
+	"过年": {weightScene, []string{"过年"}},
+	"新年": {weightScene, []string{"新年快乐"}},
+	"恋爱": {weightScene, []string{"恋爱"}},
+	"分手": {weightScene, []string{"分手"}},
+	"单身": {weightScene, []string{"单身狗"}},
+	// 主体
+	"猫":  {weightSubject, []string{"猫"}},
+	"猫咪": {weightSubject, []string{"猫"}},
+	"狗":  {weightSubject, []string{"狗"}},
+	"狗子": {weightSubject, []string{"狗"}},
+	"熊猫": {weightSubject, []string{"熊猫头"}},
+	"兔子": {weightSubject, []string{"兔子"}},
+	"鸭子": {weightSubject, []string{"鸭子"}},
+	"小孩": {weightSubject, []string{"小孩"}},
+}
+
+// 回退切分时忽略的虚词
+// 单字虚词只在片段首尾去除，避免拆坏 "重要"、"需要" 这类包含虚词字的词
+var (
+	// stopPhrases 多字虚词，出现在任意位置都作为分隔
+	stopPhrases = []string{"好像", "时候", "今天", "今晚", "明天", "昨天", "现在", "一个", "什么", "怎么"}
+	// leadingStopChars 片段开头的代词、副词与介词
+	leadingStopChars = "当我你他她它们这那是在有和要说就都也还又很太真"
+	// trailingStopChars 片段末尾的语气词与助词
+	trailingStopChars = "的了着过吗呢吧啊呀嘛么"
+)
+
+// maxLexiconWordLen 词典中最长词的字数，用于正向最大匹配
+var maxLexiconWordLen = func() int {
+	n := 0
+	for w := range keywordLexicon {
+		if l := len([]rune(w)); l > n {
+			n = l
+		}
+	}
+	return n
+}()
+
+// ExtractKeywords 从一句话中提取用于搜索表情包的候选关键词
+// 先基于内置词典做正向最大匹配，按情绪 > 场景 > 主体排序；
+// 词典未命中时回退为去除虚词后的短语片段。limit <= 0 表示不限制数量
+func ExtractKeywords(text string, limit int) []string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil
+	}
+
+	var hits []lexiconEntry
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		matched := false
+		for l := min(maxLexiconWordLen, len(runes)-i); l > 0; l-- {
+			if entry, ok := keywordLexicon[string(runes[i:i+l])]; ok {
+				hits = append(hits, entry)
+				i += l
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+
+	var keywords []string
+	if len(hits) > 0 {
+		// 同权重保持原文顺序
+		sort.SliceStable(hits, func(a, b int) bool {
+			return hits[a].weight > hits[b].weight
+		})
+		// 先放每个命中的主关键词，再补充扩展关键词
+		for _, h := range hits {
+			keywords = append(keywords, h.keywords[0])
+		}
+		for _, h := range hits {
+			keywords = append(keywords, h.keywords[1:]...)
+		}
+	} else {
+		keywords = fallbackKeywords(text)
+	}
+
+	keywords = uniqueStrings(keywords)
+	if limit > 0 && len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
+// fallbackKeywords 按标点、空白、多字虚词以及汉字与字母数字的交界切分，
+// 去掉片段首尾的单字虚词后，汉字片段截断为 2-4 字的短语，字母数字片段保留整个单词
+func fallbackKeywords(text string) []string {
+	for _, w := range stopPhrases {
+		text = strings.ReplaceAll(text, w, " ")
+	}
+
+	var keywords []string
+	for _, seg := range splitScripts(text) {
+		r := []rune(seg)
+		if !unicode.Is(unicode.Han, r[0]) {
+			if len(r) >= 2 {
+				keywords = append(keywords, seg)
+			}
+			continue
+		}
+
+		for len(r) > 0 && strings.ContainsRune(leadingStopChars, r[0]) {
+			r = r[1:]
+		}
+		for len(r) > 0 && strings.ContainsRune(trailingStopChars, r[len(r)-1]) {
+			r = r[:len(r)-1]
+		}
+		switch {
+		case len(r) < 2:
+			continue
+		case len(r) > 4:
+			r = r[:4]
+		}
+		keywords = append(keywords, string(r))
+	}
+	return keywords
+}
+
+// splitScripts 以标点、空白、符号分隔，并在汉字与其他文字的交界处切开
+func splitScripts(text string) []string {
+	var segments []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, string(current))
+			current = nil
+		}
+	}
+	for _, r := range text {
+		if unicode.IsPunct(r) || unicode.IsSpace(r) || unicode.IsSymbol(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 && unicode.Is(unicode.Han, current[len(current)-1]) != unicode.Is(unicode.Han, r) {
+			flush()
+		}
+		current = append(current, r)
+	}
+	flush()
+	return segments
+}
+
+// uniqueStrings 去重并保持顺序
+func uniqueStrings(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractKeywords(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  []string
+	}{
+		// 词典命中：情绪 > 场景 > 主体，再补充扩展关键词
+		{"当老板说今晚要加班的时候", 0, []string{"老板", "加班", "打工人"}},
+		{"我的猫好开心", 0, []string{"开心", "猫"}},
+		{"笑死我了哈哈", 2, []string{"笑死", "哈哈哈"}},
+		{"气死了，周一又要开会", 0, []string{"气死", "周一", "开会"}},
+		{"EMO了", 0, []string{"emo"}},
+		{"666 太强了", 0, []string{"666"}},
+		// 回退切分：虚词字在词内时保留
+		{"这很重要", 0, []string{"重要"}},
+		{"重要会议", 0, []string{"重要会议"}},
+		{"需要帮忙吗", 0, []string{"需要帮忙"}},
+		{"我想吃火锅了", 0, []string{"想吃火锅"}},
+		// 中英混合：英文单词不截断，汉字与字母交界处切开
+		{"deploy又挂了", 0, []string{"deploy"}},
+		{"今天 deadline 到了 头秃", 0, []string{"deadline", "头秃"}},
+		{"周五晚上想喝bubble tea", 0, []string{"周五晚上", "bubble", "tea"}},
+		{"", 3, nil},
+		{"。。。", 3, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := ExtractKeywords(tt.text, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractKeywords(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
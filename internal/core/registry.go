@@ -2,10 +2,18 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/shadow/meme/internal/metrics"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
 )
 
+var logger = utils.Logger("core")
+
 // Registry 源注册中心
 type Registry struct {
 	sources map[string]Source
@@ -58,117 +66,179 @@ func (r *Registry) ListIDs() []string {
 
 // SearchAll 并发搜索所有源
 func (r *Registry) SearchAll(ctx context.Context, keyword string, opts SearchOptions) SearchResult {
-	startTime := time.Now()
+	return r.SearchStream(ctx, keyword, nil, opts, nil)
+}
 
-	sources := r.List()
-	if len(sources) == 0 {
-		return SearchResult{
-			Memes:      []Meme{},
-			Sources:    []string{},
-			Errors:     map[string]string{},
-			Total:      0,
-			DurationMs: time.Since(startTime).Milliseconds(),
-		}
-	}
+// SearchSources 搜索指定的源
+func (r *Registry) SearchSources(ctx context.Context, keyword string, sourceIDs []string, opts SearchOptions) SearchResult {
+	return r.SearchStream(ctx, keyword, sourceIDs, opts, nil)
+}
 
-	// 创建结果通道
-	type sourceResult struct {
-		sourceID string
-		memes    []Meme
-		err      error
+// SearchStream 并发搜索指定的源 (为空则搜索所有源)，每个源完成时立即回调 onResult
+// onResult 在收集结果的 goroutine 中串行调用，可为 nil；返回值为最终的聚合结果
+func (r *Registry) SearchStream(ctx context.Context, keyword string, sourceIDs []string, opts SearchOptions, onResult func(SourceResult)) SearchResult {
+	var targets []Source
+	var missing []string
+	if len(sourceIDs) == 0 {
+		targets = r.List()
+	} else {
+		targets, missing = r.resolve(sourceIDs)
 	}
+	targets, skipped := filterCapable(targets, opts)
+
+	return r.fanOut(ctx, fanOutRequest{
+		operation: "search",
+		targets:   targets,
+		missing:   missing,
+		skipped:   skipped,
+		call: func(ctx context.Context, s Source, opts SearchOptions) ([]Meme, error) {
+			return s.Search(ctx, keyword, opts)
+		},
+		onResult: onResult,
+	}, opts)
+}
 
-	resultCh := make(chan sourceResult, len(sources))
-
-	// 并发请求所有源
-	var wg sync.WaitGroup
-	for _, source := range sources {
-		wg.Add(1)
-		go func(s Source) {
-			defer wg.Done()
-
-			// 为每个源创建带超时的 context
-			timeout := opts.Timeout
-			if timeout == 0 {
-				timeout = 10 * time.Second
+// Browse 并发浏览支持 Browser 接口的源
+// sourceIDs 为空时浏览所有支持该模式的源；指定了不支持该模式的源时记为跳过
+func (r *Registry) Browse(ctx context.Context, mode BrowseMode, sourceIDs []string, opts SearchOptions) SearchResult {
+	var targets []Source
+	var missing []string
+	skipped := make(map[string]string)
+	if len(sourceIDs) == 0 {
+		for _, s := range r.List() {
+			if _, ok := s.(Browser); ok && s.Capabilities().SupportsBrowse(mode) {
+				targets = append(targets, s)
 			}
-			sourceCtx, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-
-			memes, err := s.Search(sourceCtx, keyword, opts)
-			resultCh <- sourceResult{
-				sourceID: s.ID(),
-				memes:    memes,
-				err:      err,
+		}
+	} else {
+		var resolved []Source
+		resolved, missing = r.resolve(sourceIDs)
+		for _, s := range resolved {
+			if _, ok := s.(Browser); ok && s.Capabilities().SupportsBrowse(mode) {
+				targets = append(targets, s)
+			} else {
+				skipped[s.ID()] = fmt.Sprintf("browse mode %q not supported", mode)
 			}
-		}(source)
+		}
 	}
 
-	// 等待所有请求完成后关闭通道
-	go func() {
-		wg.Wait()
-		close(resultCh)
-	}()
-
-	// 收集结果
-	var allMemes []Meme
-	successSources := []string{}
-	errors := make(map[string]string)
+	return r.fanOut(ctx, fanOutRequest{
+		operation: "browse_" + string(mode),
+		targets:   targets,
+		missing:   missing,
+		skipped:   skipped,
+		call: func(ctx context.Context, s Source, opts SearchOptions) ([]Meme, error) {
+			browser, ok := s.(Browser)
+			if !ok {
+				return nil, ErrBrowseNotSupported
+			}
+			switch mode {
+			case BrowseLatest:
+				return browser.Latest(ctx, opts)
+			default:
+				return nil, ErrBrowseNotSupported
+			}
+		},
+	}, opts)
+}
 
-	for result := range resultCh {
-		if result.err != nil {
-			errors[result.sourceID] = result.err.Error()
+// resolve 将源ID解析为已注册的源，返回找不到的ID
+func (r *Registry) resolve(sourceIDs []string) ([]Source, []string) {
+	var targets []Source
+	var missing []string
+	for _, id := range sourceIDs {
+		if source, ok := r.Get(id); ok {
+			targets = append(targets, source)
 		} else {
-			successSources = append(successSources, result.sourceID)
-			allMemes = append(allMemes, result.memes...)
+			missing = append(missing, id)
 		}
 	}
+	return targets, missing
+}
 
-	// 去重
-	allMemes = DeduplicateMemes(allMemes)
-
-	return SearchResult{
-		Memes:      allMemes,
-		Sources:    successSources,
-		Errors:     errors,
-		Total:      len(allMemes),
-		DurationMs: time.Since(startTime).Milliseconds(),
+// filterCapable 根据能力描述过滤无法满足本次请求的源，返回可用的源及跳过原因
+func filterCapable(sources []Source, opts SearchOptions) ([]Source, map[string]string) {
+	capable := make([]Source, 0, len(sources))
+	skipped := make(map[string]string)
+	for _, s := range sources {
+		caps := s.Capabilities()
+		// 不支持翻页的源在非首页只会重复返回第一页
+		if opts.Page > 1 && !caps.SupportsPagination {
+			skipped[s.ID()] = "pagination not supported"
+			continue
+		}
+		capable = append(capable, s)
 	}
+	return capable, skipped
 }
 
-// SearchSources 搜索指定的源
-func (r *Registry) SearchSources(ctx context.Context, keyword string, sourceIDs []string, opts SearchOptions) SearchResult {
-	startTime := time.Now()
+// sourceOptions 按源的能力调整请求参数：Limit 超过单页上限 (MaxPageSize) 时按上限请求
+func sourceOptions(caps Capabilities, opts SearchOptions) SearchOptions {
+	if caps.MaxPageSize > 0 && opts.Limit > caps.MaxPageSize {
+		opts.Limit = caps.MaxPageSize
+	}
+	return opts
+}
 
-	if len(sourceIDs) == 0 {
-		return r.SearchAll(ctx, keyword, opts)
+// applyLimit 截断不遵守 Limit 的源返回的多余结果
+func applyLimit(caps Capabilities, memes []Meme, limit int) []Meme {
+	if !caps.HonorsLimit && limit > 0 && len(memes) > limit {
+		return memes[:limit]
 	}
+	return memes
+}
+
+// fanOutRequest 一次并发请求的目标与回调
+type fanOutRequest struct {
+	operation string            // 操作名，用于指标标签，如 search、browse_latest
+	targets   []Source          // 需要请求的源
+	missing   []string          // 找不到的源ID，记为 ErrSourceNotFound
+	skipped   map[string]string // 跳过的源及原因，原样写入结果
+	call      func(ctx context.Context, s Source, opts SearchOptions) ([]Meme, error)
+	onResult  func(SourceResult) // 可选，每个源完成时回调
+}
+
+// fanOut 并发对每个源执行 call 并聚合结果
+// opts.MinResults > 0 时，去重后结果数达到阈值即取消其余源并提前返回；
+// opts.Deadline > 0 时，到达整体截止时间后不再等待，未完成的源记为已取消
+func (r *Registry) fanOut(ctx context.Context, req fanOutRequest, opts SearchOptions) SearchResult {
+	startTime := time.Now()
 
-	// 创建结果通道
-	type sourceResult struct {
-		sourceID string
-		memes    []Meme
-		err      error
+	// 同一次搜索中所有源的日志共享请求ID
+	ctx = utils.EnsureRequestID(ctx)
+
+	ctx, span := tracing.Start(ctx, "registry."+req.operation,
+		tracing.Int("registry.targets", len(req.targets)),
+		tracing.Int("registry.missing", len(req.missing)),
+		tracing.Int("registry.skipped", len(req.skipped)),
+	)
+	defer span.End()
+
+	// 用于提前返回时取消尚未完成的源
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+	if opts.Deadline > 0 {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithTimeout(ctx, opts.Deadline)
+		defer cancelDeadline()
 	}
 
-	resultCh := make(chan sourceResult, len(sourceIDs))
+	total := len(req.targets) + len(req.missing)
+	resultCh := make(chan SourceResult, total)
 
-	// 并发请求指定源
-	var wg sync.WaitGroup
-	for _, id := range sourceIDs {
-		source, ok := r.Get(id)
-		if !ok {
-			resultCh <- sourceResult{
-				sourceID: id,
-				err:      ErrSourceNotFound,
-			}
-			continue
+	for _, id := range req.missing {
+		resultCh <- SourceResult{
+			SourceID: id,
+			Err:      ErrSourceNotFound,
 		}
+	}
 
-		wg.Add(1)
+	// 并发请求所有源
+	pending := make(map[string]bool, len(req.targets))
+	for _, source := range req.targets {
+		pending[source.ID()] = true
 		go func(s Source) {
-			defer wg.Done()
-
+			// 为每个源创建带超时的 context
 			timeout := opts.Timeout
 			if timeout == 0 {
 				timeout = 10 * time.Second
@@ -176,43 +246,183 @@ func (r *Registry) SearchSources(ctx context.Context, keyword string, sourceIDs
 			sourceCtx, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
 
-			memes, err := s.Search(sourceCtx, keyword, opts)
-			resultCh <- sourceResult{
-				sourceID: s.ID(),
-				memes:    memes,
-				err:      err,
+			sourceCtx, sourceSpan := tracing.Start(sourceCtx, "source."+req.operation, tracing.String("source.id", s.ID()))
+			defer sourceSpan.End()
+
+			sourceStart := time.Now()
+			sourceOpts := sourceOptions(s.Capabilities(), opts)
+			memes, hedged, err := callHedged(sourceCtx, s, func(ctx context.Context, s Source) ([]Meme, error) {
+				return req.call(ctx, s, sourceOpts)
+			}, opts.HedgeAfter)
+			memes = applyLimit(s.Capabilities(), memes, opts.Limit)
+			sourceSpan.SetAttributes(tracing.Int("source.results", len(memes)), tracing.Bool("source.hedged", hedged))
+			sourceSpan.RecordError(err)
+			resultCh <- SourceResult{
+				SourceID:   s.ID(),
+				Memes:      memes,
+				Err:        err,
+				Hedged:     hedged,
+				DurationMs: time.Since(sourceStart).Milliseconds(),
 			}
 		}(source)
 	}
 
-	go func() {
-		wg.Wait()
-		close(resultCh)
-	}()
-
-	// 收集结果
-	var allMemes []Meme
+	// 收集结果 (resultCh 带缓冲，提前返回后迟到的源不会阻塞)
+	allMemes := []Meme{}
 	successSources := []string{}
 	errors := make(map[string]string)
+	var hedgedSources []string
+	seen := make(map[string]bool)
+	unique := 0
+	done := 0
+
+collect:
+	for done < total {
+		var result SourceResult
+		select {
+		case result = <-resultCh:
+		case <-ctx.Done():
+			break collect
+		}
+
+		done++
+		delete(pending, result.SourceID)
+		if result.Err != ErrSourceNotFound {
+			metrics.SourceDuration.Observe(float64(result.DurationMs)/1000, result.SourceID, req.operation)
+		}
+		if result.Hedged {
+			hedgedSources = append(hedgedSources, result.SourceID)
+		}
+
+		// 整体被取消导致的失败不算源自身的错误
+		if result.Err != nil && ctx.Err() != nil {
+			pending[result.SourceID] = true
+			break
+		}
 
-	for result := range resultCh {
-		if result.err != nil {
-			errors[result.sourceID] = result.err.Error()
+		if result.Err != nil {
+			logger.WarnContext(ctx, "source failed", "operation", req.operation, "source", result.SourceID,
+				"kind", ErrorKind(result.Err), "duration_ms", result.DurationMs, "error", result.Err)
+			result.Error = result.Err.Error()
+			errors[result.SourceID] = result.Error
+			// 未注册的源 ID 来自调用方输入，不作为标签计入指标，避免序列数量无限增长
+			if result.Err != ErrSourceNotFound {
+				metrics.SourceRequests.Inc(result.SourceID, req.operation, "error")
+				metrics.SourceErrors.Inc(result.SourceID, ErrorKind(result.Err))
+			}
 		} else {
-			successSources = append(successSources, result.sourceID)
-			allMemes = append(allMemes, result.memes...)
+			logger.DebugContext(ctx, "source completed", "operation", req.operation, "source", result.SourceID,
+				"results", len(result.Memes), "hedged", result.Hedged, "duration_ms", result.DurationMs)
+			metrics.SourceRequests.Inc(result.SourceID, req.operation, "success")
+			metrics.SourceResults.Observe(float64(len(result.Memes)), result.SourceID)
+			successSources = append(successSources, result.SourceID)
+			allMemes = append(allMemes, result.Memes...)
+			for _, meme := range result.Memes {
+				if key := ExtractURLKey(meme.URL); !seen[key] {
+					seen[key] = true
+					unique++
+				}
+			}
+		}
+
+		if req.onResult != nil {
+			result.Done = done
+			result.Total = total
+			req.onResult(result)
+		}
+
+		if opts.MinResults > 0 && unique >= opts.MinResults {
+			break
 		}
 	}
 
+	// 尚未完成的源均视为被取消
+	var cancelled []string
+	for id := range pending {
+		cancelled = append(cancelled, id)
+		metrics.SourceRequests.Inc(id, req.operation, "cancelled")
+	}
+	sort.Strings(cancelled)
+
+	// 去重
+	_, dedupSpan := tracing.Start(ctx, "dedup", tracing.Int("dedup.input", len(allMemes)))
+	metrics.DedupMemes.Add(float64(len(allMemes)), "input")
 	allMemes = DeduplicateMemes(allMemes)
+	metrics.DedupMemes.Add(float64(len(allMemes)), "output")
+	dedupSpan.SetAttributes(tracing.Int("dedup.output", len(allMemes)))
+	dedupSpan.End()
+
+	span.SetAttributes(
+		tracing.Int("registry.succeeded", len(successSources)),
+		tracing.Int("registry.failed", len(errors)),
+		tracing.Int("registry.cancelled", len(cancelled)),
+		tracing.Int("registry.results", len(allMemes)),
+	)
+
+	logger.InfoContext(ctx, "fan-out completed", "operation", req.operation,
+		"succeeded", len(successSources), "failed", len(errors), "cancelled", len(cancelled),
+		"results", len(allMemes), "duration_ms", time.Since(startTime).Milliseconds())
+
+	metrics.Searches.Inc(req.operation)
+	metrics.SearchDuration.Observe(time.Since(startTime).Seconds(), req.operation)
 
 	return SearchResult{
 		Memes:      allMemes,
 		Sources:    successSources,
 		Errors:     errors,
+		Skipped:    req.skipped,
+		Cancelled:  cancelled,
+		Hedged:     hedgedSources,
 		Total:      len(allMemes),
 		DurationMs: time.Since(startTime).Milliseconds(),
+		TraceID:    span.TraceID.String(),
+	}
+}
+
+// callHedged 调用源，若 hedgeAfter 后仍未返回则再发起一次相同请求，取先成功的结果
+// 声明了速率限制的源不做对冲，避免触发限流
+func callHedged(ctx context.Context, s Source, call func(ctx context.Context, s Source) ([]Meme, error), hedgeAfter time.Duration) ([]Meme, bool, error) {
+	if hedgeAfter <= 0 || s.Capabilities().RateLimitPerMinute > 0 {
+		memes, err := call(ctx, s)
+		return memes, false, err
+	}
+
+	type attempt struct {
+		memes []Meme
+		err   error
+	}
+
+	// 任一请求成功后取消另一个
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attemptCh := make(chan attempt, 2)
+	launch := func() {
+		memes, err := call(ctx, s)
+		attemptCh <- attempt{memes: memes, err: err}
+	}
+
+	go launch()
+
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	select {
+	case a := <-attemptCh:
+		return a.memes, false, a.err
+	case <-timer.C:
+		tracing.SpanFromContext(ctx).AddEvent("hedge")
+		go launch()
+	}
+
+	var last attempt
+	for i := 0; i < 2; i++ {
+		last = <-attemptCh
+		if last.err == nil {
+			break
+		}
 	}
+	return last.memes, true, last.err
 }
 
 // 全局默认注册中心
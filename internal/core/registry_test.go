@@ -0,0 +1,482 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shadow/meme/internal/metrics"
+)
+
+// failingSource 每次搜索都返回固定错误的源
+type failingSource struct{ err error }
+
+func (s failingSource) ID() string                 { return "broken" }
+func (s failingSource) Name() string               { return "broken" }
+func (s failingSource) Description() string        { return "" }
+func (s failingSource) RequiresAuth() bool         { return false }
+func (s failingSource) Capabilities() Capabilities { return Capabilities{} }
+func (s failingSource) Search(context.Context, string, SearchOptions) ([]Meme, error) {
+	return nil, s.err
+}
+
+func TestSearchStreamReportsError(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(failingSource{err: errors.New("unexpected status code: 503")})
+
+	var streamed []SourceResult
+	registry.SearchStream(context.Background(), "猫", []string{"broken", "missing"}, DefaultSearchOptions(), func(r SourceResult) {
+		streamed = append(streamed, r)
+	})
+
+	want := map[string]string{
+		"broken":  "unexpected status code: 503",
+		"missing": ErrSourceNotFound.Error(),
+	}
+	if len(streamed) != len(want) {
+		t.Fatalf("streamed %d results, want %d", len(streamed), len(want))
+	}
+	for _, r := range streamed {
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"error":`+mustJSON(t, want[r.SourceID])) {
+			t.Errorf("%s JSON = %s, want error %q", r.SourceID, data, want[r.SourceID])
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSearchStreamSkipsMetricsForUnknownSources(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(failingSource{err: &StatusError{Code: 503}})
+	registry.SearchSources(context.Background(), "猫", []string{"broken", "no-such-source"}, DefaultSearchOptions())
+
+	var b strings.Builder
+	metrics.Default.WriteText(&b)
+	if strings.Contains(b.String(), "no-such-source") {
+		t.Errorf("metrics contain unknown source id:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), `meme_source_errors_total{source="broken",kind="http_status"}`) {
+		t.Errorf("metrics missing error for registered source:\n%s", b.String())
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{fmt.Errorf("%w: %w", ErrRequestFailed, context.DeadlineExceeded), "timeout"},
+		{ErrSourceNotFound, "not_found"},
+		{ErrBrowseNotSupported, "unsupported"},
+		{&AuthError{Source: "douyin", Reason: "cookie expired"}, "auth"},
+		{&ParseError{Source: "pdan", Selector: ".item"}, "layout"},
+		{fmt.Errorf("search: %w", &StatusError{Code: 503}), "http_status"},
+		{&DecodeError{Op: "decode JSON", Err: errors.New("unexpected EOF")}, "parse"},
+		{fmt.Errorf("%w: %w", ErrRequestFailed, errors.New("connection refused")), "network"},
+		// 仅凭错误文本不再归类
+		{errors.New("unexpected status code: 500"), "other"},
+		{errors.New("cookie missing"), "other"},
+	}
+	for _, tt := range tests {
+		if got := ErrorKind(tt.err); got != tt.want {
+			t.Errorf("ErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// latestSource 支持浏览最新列表的源，记录收到的页码
+type latestSource struct {
+	id    string
+	caps  Capabilities
+	memes []Meme
+	pages chan int
+}
+
+func (s latestSource) ID() string                 { return s.id }
+func (s latestSource) Name() string               { return s.id }
+func (s latestSource) Description() string        { return "" }
+func (s latestSource) RequiresAuth() bool         { return false }
+func (s latestSource) Capabilities() Capabilities { return s.caps }
+func (s latestSource) Search(context.Context, string, SearchOptions) ([]Meme, error) {
+	return nil, errors.New("search should not be called when browsing")
+}
+func (s latestSource) Latest(_ context.Context, opts SearchOptions) ([]Meme, error) {
+	s.pages <- opts.Page
+	return s.memes, nil
+}
+
+func TestBrowse(t *testing.T) {
+	latest := Capabilities{SupportsPagination: true, BrowseModes: []BrowseMode{BrowseLatest}}
+	newRegistry := func() (*Registry, chan int) {
+		pages := make(chan int, 10)
+		registry := NewRegistry()
+		registry.Register(latestSource{id: "doutula", caps: latest, pages: pages, memes: []Meme{
+			{Title: "猫", URL: "https://img.example.com/cat.gif", Platform: "doutula"},
+			{Title: "狗", URL: "https://img.example.com/dog.gif", Platform: "doutula"},
+		}})
+		registry.Register(latestSource{id: "pdan", caps: latest, pages: pages, memes: []Meme{
+			{Title: "猫", URL: "https://img.example.com/cat.gif", Platform: "pdan"},
+		}})
+		// 实现了 Browser 但能力中未声明浏览模式
+		registry.Register(latestSource{id: "quiet", pages: pages})
+		registry.Register(failingSource{err: errors.New("search only")})
+		return registry, pages
+	}
+
+	t.Run("all sources", func(t *testing.T) {
+		registry, pages := newRegistry()
+		opts := DefaultSearchOptions()
+		opts.Page = 2
+		result := registry.Browse(context.Background(), BrowseLatest, nil, opts)
+
+		if got := strings.Join(sortedCopy(result.Sources), ","); got != "doutula,pdan" {
+			t.Errorf("Sources = %s, want doutula,pdan", got)
+		}
+		if len(result.Skipped) != 0 || len(result.Errors) != 0 {
+			t.Errorf("Skipped = %v, Errors = %v, want none when browsing all sources", result.Skipped, result.Errors)
+		}
+		if result.Total != 2 || len(result.Memes) != 2 {
+			t.Errorf("Total = %d, memes = %d, want 2 after dedup", result.Total, len(result.Memes))
+		}
+		close(pages)
+		for page := range pages {
+			if page != 2 {
+				t.Errorf("Latest called with page %d, want 2", page)
+			}
+		}
+	})
+
+	t.Run("explicit sources", func(t *testing.T) {
+		registry, _ := newRegistry()
+		result := registry.Browse(context.Background(), BrowseLatest, []string{"pdan", "quiet", "broken", "missing"}, DefaultSearchOptions())
+
+		if got := strings.Join(result.Sources, ","); got != "pdan" {
+			t.Errorf("Sources = %s, want pdan", got)
+		}
+		want := fmt.Sprintf("browse mode %q not supported", BrowseLatest)
+		for _, id := range []string{"quiet", "broken"} {
+			if result.Skipped[id] != want {
+				t.Errorf("Skipped[%s] = %q, want %q", id, result.Skipped[id], want)
+			}
+		}
+		if result.Errors["missing"] != ErrSourceNotFound.Error() {
+			t.Errorf("Errors[missing] = %q, want %q", result.Errors["missing"], ErrSourceNotFound)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		registry, _ := newRegistry()
+		result := registry.Browse(context.Background(), BrowseMode("trending"), []string{"doutula"}, DefaultSearchOptions())
+		if len(result.Sources) != 0 || result.Skipped["doutula"] == "" {
+			t.Errorf("Sources = %v, Skipped = %v, want doutula skipped", result.Sources, result.Skipped)
+		}
+	})
+}
+
+func sortedCopy(s []string) []string {
+	out := append([]string(nil), s...)
+	sort.Strings(out)
+	return out
+}
+
+// stubSource 返回固定结果的源，记录每次搜索收到的参数
+type stubSource struct {
+	id    string
+	caps  Capabilities
+	memes []Meme
+	opts  chan SearchOptions
+}
+
+func (s stubSource) ID() string                 { return s.id }
+func (s stubSource) Name() string               { return s.id }
+func (s stubSource) Description() string        { return "" }
+func (s stubSource) RequiresAuth() bool         { return false }
+func (s stubSource) Capabilities() Capabilities { return s.caps }
+func (s stubSource) Search(_ context.Context, _ string, opts SearchOptions) ([]Meme, error) {
+	if s.opts != nil {
+		s.opts <- opts
+	}
+	return s.memes, nil
+}
+
+func numberedMemes(platform string, n int) []Meme {
+	memes := make([]Meme, n)
+	for i := range memes {
+		memes[i] = Meme{Title: fmt.Sprint(i), URL: fmt.Sprintf("https://img.example.com/%s/%d.gif", platform, i), Platform: platform}
+	}
+	return memes
+}
+
+func TestSearchCapabilities(t *testing.T) {
+	t.Run("pagination not supported", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(stubSource{id: "paged", caps: Capabilities{SupportsPagination: true, HonorsLimit: true}, memes: numberedMemes("paged", 1)})
+		registry.Register(stubSource{id: "single", caps: Capabilities{HonorsLimit: true}, memes: numberedMemes("single", 1)})
+
+		opts := DefaultSearchOptions()
+		opts.Page = 2
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		if got := strings.Join(result.Sources, ","); got != "paged" {
+			t.Errorf("Sources = %s, want paged", got)
+		}
+		if result.Skipped["single"] != "pagination not supported" {
+			t.Errorf("Skipped = %v, want single skipped for pagination", result.Skipped)
+		}
+
+		opts.Page = 1
+		if result := registry.SearchAll(context.Background(), "猫", opts); len(result.Skipped) != 0 || len(result.Sources) != 2 {
+			t.Errorf("first page: Sources = %v, Skipped = %v, want both searched", result.Sources, result.Skipped)
+		}
+	})
+
+	t.Run("limit above max page size", func(t *testing.T) {
+		got := make(chan SearchOptions, 1)
+		registry := NewRegistry()
+		registry.Register(stubSource{id: "small", caps: Capabilities{MaxPageSize: 10, HonorsLimit: true}, opts: got})
+
+		opts := DefaultSearchOptions()
+		opts.Limit = 50
+		registry.SearchAll(context.Background(), "猫", opts)
+		if limit := (<-got).Limit; limit != 10 {
+			t.Errorf("source received Limit %d, want capped to 10", limit)
+		}
+	})
+
+	t.Run("limit not honored", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(stubSource{id: "greedy", caps: Capabilities{}, memes: numberedMemes("greedy", 30)})
+		registry.Register(stubSource{id: "polite", caps: Capabilities{HonorsLimit: true}, memes: numberedMemes("polite", 7)})
+
+		opts := DefaultSearchOptions()
+		opts.Limit = 5
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		counts := make(map[string]int)
+		for _, m := range result.Memes {
+			counts[m.Platform]++
+		}
+		// 遵守 Limit 的源由源自身负责，注册中心只截断不遵守的源
+		if counts["greedy"] != 5 || counts["polite"] != 7 {
+			t.Errorf("results per source = %v, want greedy truncated to 5 and polite untouched", counts)
+		}
+	})
+}
+
+// blockingSource 在 context 取消前一直阻塞，取消时通知 cancelled
+type blockingSource struct {
+	id        string
+	cancelled chan struct{}
+}
+
+func (s blockingSource) ID() string                 { return s.id }
+func (s blockingSource) Name() string               { return s.id }
+func (s blockingSource) Description() string        { return "" }
+func (s blockingSource) RequiresAuth() bool         { return false }
+func (s blockingSource) Capabilities() Capabilities { return Capabilities{HonorsLimit: true} }
+func (s blockingSource) Search(ctx context.Context, _ string, _ SearchOptions) ([]Meme, error) {
+	<-ctx.Done()
+	close(s.cancelled)
+	return nil, ctx.Err()
+}
+
+// flakySource 按调用顺序执行 attempts 中的函数，用于模拟对冲请求
+type flakySource struct {
+	id       string
+	caps     Capabilities
+	calls    *atomic.Int32
+	attempts []func(ctx context.Context) ([]Meme, error)
+}
+
+func (s flakySource) ID() string                 { return s.id }
+func (s flakySource) Name() string               { return s.id }
+func (s flakySource) Description() string        { return "" }
+func (s flakySource) RequiresAuth() bool         { return false }
+func (s flakySource) Capabilities() Capabilities { return s.caps }
+func (s flakySource) Search(ctx context.Context, _ string, _ SearchOptions) ([]Meme, error) {
+	n := int(s.calls.Add(1))
+	if n > len(s.attempts) {
+		return nil, fmt.Errorf("unexpected attempt %d", n)
+	}
+	return s.attempts[n-1](ctx)
+}
+
+// waitClosed 等待通道关闭，超时视为失败
+func waitClosed(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s: not cancelled", what)
+	}
+}
+
+func TestFanOutEarlyReturn(t *testing.T) {
+	t.Run("min results", func(t *testing.T) {
+		slow := blockingSource{id: "slow", cancelled: make(chan struct{})}
+		registry := NewRegistry()
+		registry.Register(stubSource{id: "fast", caps: Capabilities{HonorsLimit: true}, memes: numberedMemes("fast", 3)})
+		registry.Register(slow)
+
+		opts := DefaultSearchOptions()
+		opts.MinResults = 2
+		result := registry.SearchAll(context.Background(), "猫", opts)
+
+		if len(result.Memes) != 3 || strings.Join(result.Sources, ",") != "fast" {
+			t.Errorf("Memes = %d, Sources = %v, want the 3 results from fast", len(result.Memes), result.Sources)
+		}
+		if strings.Join(result.Cancelled, ",") != "slow" || len(result.Errors) != 0 {
+			t.Errorf("Cancelled = %v, Errors = %v, want slow cancelled without error", result.Cancelled, result.Errors)
+		}
+		waitClosed(t, slow.cancelled, "slow source after MinResults")
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		slow := blockingSource{id: "slow", cancelled: make(chan struct{})}
+		registry := NewRegistry()
+		registry.Register(stubSource{id: "fast", caps: Capabilities{HonorsLimit: true}, memes: numberedMemes("fast", 1)})
+		registry.Register(slow)
+
+		opts := DefaultSearchOptions()
+		opts.Deadline = 50 * time.Millisecond
+		start := time.Now()
+		result := registry.SearchAll(context.Background(), "猫", opts)
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("returned after %v, want about the 50ms deadline", elapsed)
+		}
+		if len(result.Memes) != 1 || strings.Join(result.Sources, ",") != "fast" {
+			t.Errorf("Memes = %d, Sources = %v, want the result from fast", len(result.Memes), result.Sources)
+		}
+		// 整体截止导致的失败记为取消，而不是源自身的错误
+		if strings.Join(result.Cancelled, ",") != "slow" {
+			t.Errorf("Cancelled = %v, want slow", result.Cancelled)
+		}
+		if _, ok := result.Errors["slow"]; ok {
+			t.Errorf("Errors = %v, want slow reported as cancelled only", result.Errors)
+		}
+		waitClosed(t, slow.cancelled, "slow source after Deadline")
+	})
+}
+
+func TestFanOutHedging(t *testing.T) {
+	won := numberedMemes("hedge", 2)
+	hang := func(cancelled chan struct{}) func(ctx context.Context) ([]Meme, error) {
+		return func(ctx context.Context) ([]Meme, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}
+	}
+	succeed := func(context.Context) ([]Meme, error) { return won, nil }
+	fail := func(context.Context) ([]Meme, error) { return nil, &StatusError{Code: 503} }
+
+	t.Run("second attempt wins", func(t *testing.T) {
+		loser := make(chan struct{})
+		calls := new(atomic.Int32)
+		registry := NewRegistry()
+		registry.Register(flakySource{id: "flaky", calls: calls, attempts: []func(context.Context) ([]Meme, error){hang(loser), succeed}})
+
+		opts := DefaultSearchOptions()
+		opts.HedgeAfter = 20 * time.Millisecond
+		start := time.Now()
+		result := registry.SearchAll(context.Background(), "猫", opts)
+
+		if elapsed := time.Since(start); elapsed < opts.HedgeAfter {
+			t.Errorf("returned after %v, want the hedge to fire after %v", elapsed, opts.HedgeAfter)
+		}
+		if strings.Join(result.Hedged, ",") != "flaky" || strings.Join(result.Sources, ",") != "flaky" {
+			t.Errorf("Hedged = %v, Sources = %v, want flaky hedged and successful", result.Hedged, result.Sources)
+		}
+		if len(result.Memes) != len(won) || len(result.Errors) != 0 {
+			t.Errorf("Memes = %d, Errors = %v, want the hedged attempt's results", len(result.Memes), result.Errors)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("attempts = %d, want 2", calls.Load())
+		}
+		waitClosed(t, loser, "first attempt after the hedge succeeded")
+	})
+
+	t.Run("first attempt fails then hedge succeeds", func(t *testing.T) {
+		calls := new(atomic.Int32)
+		slowFail := func(ctx context.Context) ([]Meme, error) {
+			time.Sleep(40 * time.Millisecond)
+			return fail(ctx)
+		}
+		registry := NewRegistry()
+		registry.Register(flakySource{id: "flaky", calls: calls, attempts: []func(context.Context) ([]Meme, error){slowFail, func(ctx context.Context) ([]Meme, error) {
+			time.Sleep(80 * time.Millisecond)
+			return succeed(ctx)
+		}}})
+
+		opts := DefaultSearchOptions()
+		opts.HedgeAfter = 10 * time.Millisecond
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		if len(result.Memes) != len(won) || len(result.Errors) != 0 || strings.Join(result.Hedged, ",") != "flaky" {
+			t.Errorf("Memes = %d, Errors = %v, Hedged = %v, want the later success to win", len(result.Memes), result.Errors, result.Hedged)
+		}
+	})
+
+	t.Run("both attempts fail", func(t *testing.T) {
+		calls := new(atomic.Int32)
+		slowFail := func(ctx context.Context) ([]Meme, error) {
+			time.Sleep(40 * time.Millisecond)
+			return fail(ctx)
+		}
+		registry := NewRegistry()
+		registry.Register(flakySource{id: "flaky", calls: calls, attempts: []func(context.Context) ([]Meme, error){slowFail, fail}})
+
+		opts := DefaultSearchOptions()
+		opts.HedgeAfter = 10 * time.Millisecond
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		if result.Errors["flaky"] != "unexpected status code: 503" || strings.Join(result.Hedged, ",") != "flaky" {
+			t.Errorf("Errors = %v, Hedged = %v, want flaky hedged and failed", result.Errors, result.Hedged)
+		}
+	})
+
+	t.Run("fast response is not hedged", func(t *testing.T) {
+		calls := new(atomic.Int32)
+		registry := NewRegistry()
+		registry.Register(flakySource{id: "flaky", calls: calls, attempts: []func(context.Context) ([]Meme, error){succeed}})
+
+		opts := DefaultSearchOptions()
+		opts.HedgeAfter = time.Second
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		if len(result.Hedged) != 0 || calls.Load() != 1 || len(result.Memes) != len(won) {
+			t.Errorf("Hedged = %v, attempts = %d, memes = %d, want a single attempt", result.Hedged, calls.Load(), len(result.Memes))
+		}
+	})
+
+	t.Run("rate limited source is not hedged", func(t *testing.T) {
+		calls := new(atomic.Int32)
+		slow := func(ctx context.Context) ([]Meme, error) {
+			time.Sleep(40 * time.Millisecond)
+			return succeed(ctx)
+		}
+		registry := NewRegistry()
+		registry.Register(flakySource{id: "flaky", caps: Capabilities{RateLimitPerMinute: 30}, calls: calls, attempts: []func(context.Context) ([]Meme, error){slow}})
+
+		opts := DefaultSearchOptions()
+		opts.HedgeAfter = 10 * time.Millisecond
+		result := registry.SearchAll(context.Background(), "猫", opts)
+		if len(result.Hedged) != 0 || calls.Load() != 1 {
+			t.Errorf("Hedged = %v, attempts = %d, want no hedge for a rate limited source", result.Hedged, calls.Load())
+		}
+	})
+}
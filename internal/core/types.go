@@ -7,13 +7,21 @@ import (
 
 // Meme 表情包数据结构
 type Meme struct {
+	ID       string `json:"id,omitempty"` // 稳定标识，可通过 meme://image/{id} 回查
 	Title    string `json:"title"`
 	URL      string `json:"url"`
 	Platform string `json:"platform"`
 	// 可选元数据
-	Width  int    `json:"width,omitempty"`
-	Height int    `json:"height,omitempty"`
-	Format string `json:"format,omitempty"` // gif, png, jpg, webp
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Format     string   `json:"format,omitempty"`     // gif, png, jpg, webp
+	Thumbnail  string   `json:"thumbnail,omitempty"`  // 预览图，没有单独缩略图时与 URL 相同
+	Alternates []string `json:"alternates,omitempty"` // 备用地址 (镜像、原图或未经代理的直链)，URL 失效时依次尝试
+}
+
+// URLs 返回主地址及所有备用地址，按尝试顺序排列
+func (m Meme) URLs() []string {
+	return append([]string{m.URL}, m.Alternates...)
 }
 
 // SearchOptions 搜索选项
@@ -21,6 +29,12 @@ type SearchOptions struct {
 	Page    int
 	Limit   int
 	Timeout time.Duration
+	// MinResults > 0 时，去重后结果数达到该值即提前返回，不再等待其余源
+	MinResults int
+	// Deadline > 0 时为整个请求的截止时间，Timeout 仍作用于单个源
+	Deadline time.Duration
+	// HedgeAfter > 0 时，源超过该时间未返回则再发起一次相同请求，取先成功的结果
+	HedgeAfter time.Duration
 }
 
 // DefaultSearchOptions 返回默认搜索选项
@@ -44,13 +58,68 @@ type Source interface {
 	Search(ctx context.Context, keyword string, opts SearchOptions) ([]Meme, error)
 	// RequiresAuth 是否需要认证 (如 Cookie)
 	RequiresAuth() bool
+	// Capabilities 返回源的能力描述
+	Capabilities() Capabilities
+}
+
+// Capabilities 源能力描述，供调用方选择合适的源，也用于注册中心跳过无法满足请求的源
+type Capabilities struct {
+	SupportsPagination bool         `json:"supports_pagination"`             // 是否支持 Page 翻页
+	MaxPageSize        int          `json:"max_page_size,omitempty"`         // 单页最大结果数，0 表示未知；Limit 超出时按此值请求
+	HonorsLimit        bool         `json:"honors_limit"`                    // 是否遵守 Limit，不遵守时由注册中心截断结果
+	NeedsProxy         bool         `json:"needs_proxy"`                     // 图片是否需要配置 IMAGE_PROXY_URL 才能访问，仅供调用方参考
+	ProvidesDimensions bool         `json:"provides_dimensions"`             // 结果是否包含宽高
+	BrowseModes        []BrowseMode `json:"browse_modes,omitempty"`          // 支持的浏览模式
+	Languages          []string     `json:"languages,omitempty"`             // 关键词语言，如 zh
+	Formats            []string     `json:"formats,omitempty"`               // 常见图片格式
+	RateLimitPerMinute int          `json:"rate_limit_per_minute,omitempty"` // 建议的每分钟最大请求数，0 表示不限制；非 0 时不做对冲请求
+}
+
+// SupportsBrowse 是否支持指定浏览模式
+func (c Capabilities) SupportsBrowse(mode BrowseMode) bool {
+	for _, m := range c.BrowseModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// BrowseMode 浏览模式
+type BrowseMode string
+
+// 目前只有最新列表；各站点的热门列表接口未确认可用，暂不提供热门模式
+const (
+	BrowseLatest BrowseMode = "latest" // 最新
+)
+
+// Browser 可选接口 - 支持无关键词浏览最新列表的源实现此接口
+type Browser interface {
+	// Latest 返回最新表情包
+	Latest(ctx context.Context, opts SearchOptions) ([]Meme, error)
+}
+
+// SourceResult 单个源的搜索结果，用于流式返回
+type SourceResult struct {
+	SourceID   string `json:"source"`
+	Memes      []Meme `json:"memes"`
+	Err        error  `json:"-"`
+	Error      string `json:"error,omitempty"`  // 失败原因，即 Err 的文本，便于 JSON 输出
+	Hedged     bool   `json:"hedged,omitempty"` // 是否发起过对冲请求
+	DurationMs int64  `json:"duration_ms"`
+	Done       int    `json:"done"`  // 已完成的源数量 (含本次)
+	Total      int    `json:"total"` // 本次请求的源总数
 }
 
 // SearchResult 聚合搜索结果
 type SearchResult struct {
 	Memes      []Meme            `json:"memes"`
-	Sources    []string          `json:"sources"`     // 成功的源
-	Errors     map[string]string `json:"errors"`      // 失败的源及原因
+	Sources    []string          `json:"sources"`             // 成功的源
+	Errors     map[string]string `json:"errors"`              // 失败的源及原因
+	Skipped    map[string]string `json:"skipped,omitempty"`   // 因能力不满足而跳过的源及原因
+	Cancelled  []string          `json:"cancelled,omitempty"` // 因提前返回或整体截止而取消的源
+	Hedged     []string          `json:"hedged,omitempty"`    // 发起过对冲请求的源
 	Total      int               `json:"total"`
 	DurationMs int64             `json:"duration_ms"`
+	TraceID    string            `json:"trace_id,omitempty"` // 本次请求的链路ID，用于在追踪后端中查找
 }
@@ -0,0 +1,209 @@
+package douyinsign
+
+import (
+	"crypto/rand"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Web 端版本信息，与抖音网页版保持一致
+// WebAID 为应用 ID：本包目前只服务于表情接口 (im/resource/emoticon)，网页版请求这些接口时使用 1128；
+// 抖音主站的视频、用户等接口使用 6383，需要时由调用方在 params 中传入 aid 覆盖
+const (
+	WebAID         = "1128"
+	WebVersionCode = "190500"
+	WebVersionName = "19.5.0"
+	WebChannel     = "channel_pc_web"
+)
+
+// DefaultUserAgent 默认的桌面 Chrome User-Agent，签名与请求头必须使用同一个值
+const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36"
+
+// 随机串字符集
+const (
+	tokenCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	fpCharset    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	msTokenLen   = 107
+)
+
+var (
+	chromeVersionPattern  = regexp.MustCompile(`Chrome/([\d.]+)`)
+	edgeVersionPattern    = regexp.MustCompile(`Edg/([\d.]+)`)
+	firefoxVersionPattern = regexp.MustCompile(`Firefox/([\d.]+)`)
+	safariVersionPattern  = regexp.MustCompile(`Version/([\d.]+)`)
+	macVersionPattern     = regexp.MustCompile(`Mac OS X ([\d_]+)`)
+	webkitVersionPattern  = regexp.MustCompile(`AppleWebKit/([\d.]+)`)
+)
+
+// Signer 为抖音 Web 接口补全公共参数并计算 X-Bogus 签名
+type Signer struct {
+	UserAgent string
+	Now       func() time.Time // 可替换，便于测试
+	Rand      io.Reader        // 随机源，可替换，便于测试
+}
+
+// NewSigner 创建签名器，userAgent 为空时使用 DefaultUserAgent
+func NewSigner(userAgent string) *Signer {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Signer{UserAgent: userAgent, Now: time.Now, Rand: rand.Reader}
+}
+
+// WithUserAgent 返回使用指定 User-Agent 的签名器副本 (按请求轮换 User-Agent 时使用)，空串时返回自身
+func (s *Signer) WithUserAgent(userAgent string) *Signer {
+	if userAgent == "" || userAgent == s.UserAgent {
+		return s
+	}
+	copied := *s
+	copied.UserAgent = userAgent
+	return &copied
+}
+
+// Sign 在 params 的基础上补全 Web 公共参数 (已存在的键不覆盖)，返回附带 X-Bogus 的查询串
+// cookie 为请求使用的 Cookie 键值对，其中的 msToken、s_v_web_id 会优先使用，保证与登录态一致
+func (s *Signer) Sign(params url.Values, cookie map[string]string) string {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = append([]string(nil), v...)
+	}
+	for k, v := range s.webParams(cookie) {
+		if _, ok := query[k]; !ok {
+			query.Set(k, v)
+		}
+	}
+
+	encoded := query.Encode()
+	bogus := XBogus(encoded, s.UserAgent, uint32(s.Now().Unix()))
+	return encoded + "&X-Bogus=" + bogus
+}
+
+// webParams 抖音网页版请求携带的公共参数
+func (s *Signer) webParams(cookie map[string]string) map[string]string {
+	env := parseUserAgent(s.UserAgent)
+
+	msToken := cookie["msToken"]
+	if msToken == "" {
+		msToken = s.MsToken()
+	}
+	verifyFp := cookie["s_v_web_id"]
+	if verifyFp == "" {
+		verifyFp = s.VerifyFp()
+	}
+
+	params := map[string]string{
+		"device_platform":  "webapp",
+		"aid":              WebAID,
+		"channel":          WebChannel,
+		"pc_client_type":   "1",
+		"version_code":     WebVersionCode,
+		"version_name":     WebVersionName,
+		"cookie_enabled":   "true",
+		"screen_width":     "1920",
+		"screen_height":    "1080",
+		"browser_language": "zh-CN",
+		"browser_platform": env.platform,
+		"browser_name":     env.browser,
+		"browser_version":  env.browserVersion,
+		"browser_online":   "true",
+		"engine_name":      env.engine,
+		"engine_version":   env.engineVersion,
+		"os_name":          env.os,
+		"os_version":       env.osVersion,
+		"cpu_core_num":     "8",
+		"device_memory":    "8",
+		"platform":         "PC",
+		"downlink":         "10",
+		"effective_type":   "4g",
+		"round_trip_time":  "50",
+		"msToken":          msToken,
+		"verifyFp":         verifyFp,
+		"fp":               verifyFp,
+	}
+	if webID := cookie["webid"]; webID != "" {
+		params["webid"] = webID
+	}
+	return params
+}
+
+// uaEnv 从 User-Agent 推断的浏览器与系统参数，需与请求头保持一致
+type uaEnv struct {
+	browser, browserVersion string
+	engine, engineVersion   string
+	os, osVersion, platform string
+}
+
+// parseUserAgent 识别 Chrome / Edge / Firefox / Safari 与 Windows / macOS，无法识别时按 macOS Chrome 处理
+func parseUserAgent(ua string) uaEnv {
+	env := uaEnv{browser: "Chrome", browserVersion: "139.0.0.0", engine: "Blink", os: "Mac OS", osVersion: "10.15.7", platform: "MacIntel"}
+	if m := chromeVersionPattern.FindStringSubmatch(ua); m != nil {
+		env.browserVersion = m[1]
+	}
+	env.engineVersion = env.browserVersion
+
+	switch {
+	case edgeVersionPattern.MatchString(ua):
+		env.browser, env.browserVersion = "Edge", edgeVersionPattern.FindStringSubmatch(ua)[1]
+	case firefoxVersionPattern.MatchString(ua):
+		env.browser, env.browserVersion = "Firefox", firefoxVersionPattern.FindStringSubmatch(ua)[1]
+		env.engine, env.engineVersion = "Gecko", env.browserVersion
+	case !chromeVersionPattern.MatchString(ua) && safariVersionPattern.MatchString(ua):
+		env.browser, env.browserVersion = "Safari", safariVersionPattern.FindStringSubmatch(ua)[1]
+		env.engine = "WebKit"
+		if m := webkitVersionPattern.FindStringSubmatch(ua); m != nil {
+			env.engineVersion = m[1]
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "Windows NT 10.0"):
+		env.os, env.osVersion, env.platform = "Windows", "10", "Win32"
+	case strings.Contains(ua, "Linux"):
+		env.os, env.osVersion, env.platform = "Linux", "", "Linux x86_64"
+	default:
+		if m := macVersionPattern.FindStringSubmatch(ua); m != nil {
+			env.osVersion = strings.ReplaceAll(m[1], "_", ".")
+		}
+	}
+	return env
+}
+
+// MsToken 生成随机 msToken (未登录时网页端由 SDK 生成，服务端只校验格式)
+func (s *Signer) MsToken() string {
+	return s.randomString(tokenCharset, msTokenLen)
+}
+
+// VerifyFp 生成 verifyFp / s_v_web_id：verify_<毫秒时间戳36进制>_<36位 UUID 形式随机串>
+func (s *Signer) VerifyFp() string {
+	stamp := strconv.FormatInt(s.Now().UnixMilli(), 36)
+
+	random := []byte(s.randomString(fpCharset, 36))
+	for _, i := range []int{8, 13, 18, 23} {
+		random[i] = '_'
+	}
+	random[14] = '4'
+	// 与 UUID v4 的 variant 位一致
+	random[19] = fpCharset[strings.IndexByte(fpCharset, random[19])&3|8]
+
+	return "verify_" + stamp + "_" + string(random)
+}
+
+func (s *Signer) randomString(charset string, n int) string {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.Rand, buf); err != nil {
+		// 随机源不可用时退化为时间种子，仅影响随机性
+		seed := s.Now().UnixNano()
+		for i := range buf {
+			seed = seed*6364136223846793005 + 1442695040888963407
+			buf[i] = byte(seed >> 33)
+		}
+	}
+	for i, b := range buf {
+		buf[i] = charset[int(b)%len(charset)]
+	}
+	return string(buf)
+}
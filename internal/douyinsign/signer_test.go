@@ -0,0 +1,158 @@
+package douyinsign
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// zeroReader 固定输出 0 的随机源，使生成结果可预测
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func newTestSigner() *Signer {
+	return &Signer{
+		UserAgent: macChromeUA,
+		Now:       func() time.Time { return time.Unix(1700000000, 0) },
+		Rand:      zeroReader{},
+	}
+}
+
+func TestSignAddsWebParamsAndSignature(t *testing.T) {
+	s := newTestSigner()
+	params := url.Values{"keyword": {"猫"}}
+
+	signed := s.Sign(params, nil)
+
+	encoded, bogus, ok := strings.Cut(signed, "&X-Bogus=")
+	if !ok {
+		t.Fatalf("Sign() = %q, missing X-Bogus", signed)
+	}
+	// 固定时钟 (1700000000)、全零随机源与 macChromeUA 下的签名，参数或签名算法变化时需要更新
+	if want := "DFSzswVYlpzAN9dxtmWx-e9WX7r0"; bogus != want {
+		t.Errorf("X-Bogus = %s, want %s", bogus, want)
+	}
+
+	query, err := url.ParseQuery(encoded)
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	tests := map[string]string{
+		"aid":             WebAID,
+		"keyword":         "猫",
+		"device_platform": "webapp",
+		"version_code":    WebVersionCode,
+		"browser_version": "139.0.0.0",
+		"msToken":         strings.Repeat("A", msTokenLen),
+	}
+	for key, want := range tests {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if query.Get("verifyFp") == "" || query.Get("verifyFp") != query.Get("fp") {
+		t.Errorf("verifyFp = %q, fp = %q, want equal and non-empty", query.Get("verifyFp"), query.Get("fp"))
+	}
+}
+
+func TestSignKeepsCallerParams(t *testing.T) {
+	query, err := url.ParseQuery(strings.SplitN(newTestSigner().Sign(url.Values{"aid": {"6383"}, "channel": {"x"}}, nil), "&X-Bogus=", 2)[0])
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	if query.Get("aid") != "6383" || query.Get("channel") != "x" {
+		t.Errorf("aid = %q, channel = %q, want caller values", query.Get("aid"), query.Get("channel"))
+	}
+}
+
+func TestSignPrefersCookieTokens(t *testing.T) {
+	s := newTestSigner()
+	cookie := map[string]string{
+		"msToken":    "cookie-token",
+		"s_v_web_id": "verify_cookie",
+		"webid":      "7300000000000000000",
+	}
+
+	query, err := url.ParseQuery(strings.SplitN(s.Sign(url.Values{}, cookie), "&X-Bogus=", 2)[0])
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	if got := query.Get("msToken"); got != "cookie-token" {
+		t.Errorf("msToken = %q, want cookie-token", got)
+	}
+	if got := query.Get("verifyFp"); got != "verify_cookie" {
+		t.Errorf("verifyFp = %q, want verify_cookie", got)
+	}
+	if got := query.Get("webid"); got != "7300000000000000000" {
+		t.Errorf("webid = %q, want 7300000000000000000", got)
+	}
+}
+
+func TestSignIsDeterministic(t *testing.T) {
+	params := url.Values{"keyword": {"开心"}}
+	a := newTestSigner().Sign(params, nil)
+	b := newTestSigner().Sign(params, nil)
+	if a != b {
+		t.Errorf("Sign() not deterministic with fixed clock and random source:\n%s\n%s", a, b)
+	}
+}
+
+func TestVerifyFp(t *testing.T) {
+	s := newTestSigner()
+	s.Rand = bytes.NewReader(bytes.Repeat([]byte{5}, 36))
+
+	got := s.VerifyFp()
+
+	want := regexp.MustCompile(`^verify_[0-9a-z]+_[0-9A-Za-z]{8}_[0-9A-Za-z]{4}_4[0-9A-Za-z]{3}_[0-9A-Za-z]{4}_[0-9A-Za-z]{12}$`)
+	if !want.MatchString(got) {
+		t.Fatalf("VerifyFp() = %q, does not match %s", got, want)
+	}
+	if stamp := strings.Split(got, "_")[1]; stamp != "loyw3v28" {
+		t.Errorf("timestamp part = %q, want loyw3v28", stamp)
+	}
+}
+
+func TestSignFollowsUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want map[string]string
+	}{
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0",
+			want: map[string]string{"browser_name": "Edge", "browser_platform": "Win32", "os_name": "Windows"},
+		},
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:141.0) Gecko/20100101 Firefox/141.0",
+			want: map[string]string{"browser_name": "Firefox", "browser_version": "141.0", "engine_name": "Gecko"},
+		},
+		{
+			ua:   macChromeUA,
+			want: map[string]string{"browser_name": "Chrome", "browser_platform": "MacIntel", "os_name": "Mac OS"},
+		},
+	}
+	for _, tt := range tests {
+		signer := newTestSigner().WithUserAgent(tt.ua)
+		query, err := url.ParseQuery(signer.Sign(url.Values{}, nil))
+		if err != nil {
+			t.Fatalf("parse query: %v", err)
+		}
+		for key, want := range tt.want {
+			if got := query.Get(key); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.ua, key, got, want)
+			}
+		}
+	}
+
+	if base := newTestSigner(); base.WithUserAgent("other").UserAgent == base.UserAgent {
+		t.Error("WithUserAgent did not change user agent")
+	}
+}
@@ -0,0 +1,171 @@
+package douyinsign
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const (
+	macChromeUA = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36"
+	winChromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+func TestRC4(t *testing.T) {
+	// RFC 6229 之外最常用的 RC4 公开测试向量
+	tests := []struct {
+		key, plain, want string
+	}{
+		{"Key", "Plaintext", "bbf316e8d940af0ad3"},
+		{"Wiki", "pedia", "1021bf0420"},
+		{"Secret", "Attack at dawn", "45a01f645fc35b383552544b9bf5"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(rc4([]byte(tt.plain), []byte(tt.key)))
+		if got != tt.want {
+			t.Errorf("rc4(%q, %q) = %s, want %s", tt.plain, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeTriplets(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte{0, 0, 0}, "DDDD"},
+		{[]byte{255, 255, 255}, "eeee"},
+		// 签名前两个字节固定为 0x02 0xff，因此 X-Bogus 总以 DFS 开头
+		{[]byte{2, 255, 0}, "DFSD"},
+	}
+	for _, tt := range tests {
+		if got := encodeTriplets(tt.in); got != tt.want {
+			t.Errorf("encodeTriplets(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestXBogusReference 与参考脚本 testdata/xbogus_reference.py 的输出比对
+// 该脚本按公开 Python 参考实现 (Evil0ctal/Douyin_TikTok_Download_API 的 xbogus.py) 的结构独立重写，
+// 期望值由运行该脚本得到；脚本未与原实现的实际输出或抓包的真实请求逐一核对，
+// 拿到真实请求后应追加到 TestXBogusDecode 中验证 (无需知道签名时的时间戳)
+func TestXBogusReference(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		ua    string
+		ts    uint32
+		want  string
+	}{
+		{"web params", "device_platform=webapp&aid=6383&channel=channel_pc_web", macChromeUA, 1700000000, "DFSzswVYEmGAN9dxtmWx-e9WX7ro"},
+		{"emoticon search", "aid=1128&cursor=0&device_platform=webapp&keyword=%E7%8C%AB", macChromeUA, 1712345678, "DFSzswVY2HbAN9dxt5wIKl9WX7jG"},
+		{"empty query", "", winChromeUA, 0, "DFSzswVY0IJANG//La3g-e9WX7rW"},
+		{"aweme detail", "aweme_id=7345678901234567890&aid=6383", winChromeUA, 1735689600, "DFSzswVYcCJANG//t8kkae9WX7n6"},
+		{"max timestamp", "device_platform=webapp&aid=6383&channel=channel_pc_web&keyword=%E5%BC%80%E5%BF%83&cursor=20&count=20", winChromeUA, 4294967295, "DFSzswVY7RtANG//-guaLM9WX7np"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := XBogus(tt.query, tt.ua, tt.ts)
+			if got != tt.want {
+				t.Errorf("XBogus() = %s, want %s", got, tt.want)
+			}
+			if len(got) != 28 {
+				t.Errorf("len(XBogus()) = %d, want 28", len(got))
+			}
+		})
+	}
+}
+
+// decodeXBogus 还原签名中的 19 个字段 (编码、RC4 与交错顺序均可逆)
+func decodeXBogus(t *testing.T, sig string) []byte {
+	t.Helper()
+	if len(sig) != 28 {
+		t.Fatalf("len(%q) = %d, want 28", sig, len(sig))
+	}
+	var raw []byte
+	for i := 0; i < len(sig); i += 4 {
+		var n uint32
+		for _, c := range sig[i : i+4] {
+			idx := strings.IndexRune(xbogusAlphabet[:64], c)
+			if idx < 0 {
+				t.Fatalf("%q contains %q outside the X-Bogus alphabet", sig, c)
+			}
+			n = n<<6 | uint32(idx)
+		}
+		raw = append(raw, byte(n>>16), byte(n>>8), byte(n))
+	}
+	if raw[0] != 2 || raw[1] != 255 {
+		t.Fatalf("%q prefix = %v, want [2 255]", sig, raw[:2])
+	}
+
+	shuffled := rc4(raw[2:], []byte{0xff})
+	order := []int{0, 10, 1, 11, 2, 12, 3, 13, 4, 14, 5, 15, 6, 16, 7, 17, 8, 18, 9}
+	merged := make([]byte, len(order))
+	for i, idx := range order {
+		merged[idx] = shuffled[i]
+	}
+	fields := make([]byte, len(merged))
+	for i := range fields {
+		if i%2 == 0 {
+			fields[i] = merged[i/2]
+		} else {
+			fields[i] = merged[10+i/2]
+		}
+	}
+	return fields
+}
+
+// TestXBogusDecode 解码签名并逐个检查字段：版本常量、查询串/请求体/User-Agent 哈希、时间戳、canvas 与校验和
+// 抓包得到的真实请求可直接加入此表 (query 为去掉 X-Bogus 后的原始查询串)，时间戳从签名中还原
+func TestXBogusDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		ua    string
+		sig   string
+	}{
+		{"reference web params", "device_platform=webapp&aid=6383&channel=channel_pc_web", macChromeUA, "DFSzswVYEmGAN9dxtmWx-e9WX7ro"},
+		{"reference max timestamp", "device_platform=webapp&aid=6383&channel=channel_pc_web&keyword=%E5%BC%80%E5%BF%83&cursor=20&count=20", winChromeUA, "DFSzswVY7RtANG//-guaLM9WX7np"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := decodeXBogus(t, tt.sig)
+
+			if !bytes.Equal(fields[:4], []byte{64, 0, 1, 12}) {
+				t.Errorf("header = %v, want [64 0 1 12]", fields[:4])
+			}
+			queryHash := doubleMD5([]byte(tt.query))
+			if !bytes.Equal(fields[4:6], queryHash[14:]) {
+				t.Errorf("query hash = %x, want %x", fields[4:6], queryHash[14:])
+			}
+			bodyHash := doubleMD5(nil)
+			if !bytes.Equal(fields[6:8], bodyHash[14:]) {
+				t.Errorf("body hash = %x, want %x", fields[6:8], bodyHash[14:])
+			}
+			uaHash := md5.Sum([]byte(base64.StdEncoding.EncodeToString(rc4([]byte(tt.ua), uaKey))))
+			if !bytes.Equal(fields[8:10], uaHash[14:]) {
+				t.Errorf("user agent hash = %x, want %x", fields[8:10], uaHash[14:])
+			}
+			if canvas := binary.BigEndian.Uint32(fields[14:18]); canvas != xbogusCanvas {
+				t.Errorf("canvas = %d, want %d", canvas, xbogusCanvas)
+			}
+			var checksum byte
+			for _, b := range fields[:18] {
+				checksum ^= b
+			}
+			if fields[18] != checksum {
+				t.Errorf("checksum = %d, want %d", fields[18], checksum)
+			}
+
+			// 用还原出的时间戳重新签名应得到相同结果
+			ts := binary.BigEndian.Uint32(fields[10:14])
+			if got := XBogus(tt.query, tt.ua, ts); got != tt.sig {
+				t.Errorf("XBogus() at decoded ts %d = %s, want %s", ts, got, tt.sig)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+// Default 全局默认指标注册中心
+var Default = NewRegistry()
+
+// 搜索与源相关的指标
+var (
+	// SourceRequests 单个源的请求次数，outcome 为 success/error/cancelled
+	SourceRequests = Default.NewCounterVec("meme_source_requests_total",
+		"Number of requests made to each source.", "source", "operation", "outcome")
+	// SourceDuration 单个源的请求耗时
+	SourceDuration = Default.NewHistogramVec("meme_source_request_duration_seconds",
+		"Latency of requests to each source in seconds.", DefaultBuckets, "source", "operation")
+	// SourceErrors 单个源的错误次数，按错误类型区分
+	SourceErrors = Default.NewCounterVec("meme_source_errors_total",
+		"Number of failed source requests by error kind.", "source", "kind")
+	// SourceResults 单个源每次返回的结果数
+	SourceResults = Default.NewHistogramVec("meme_source_results",
+		"Number of memes returned per source request.", []float64{0, 1, 5, 10, 20, 50, 100}, "source")
+
+	// Searches 聚合请求次数
+	Searches = Default.NewCounterVec("meme_searches_total",
+		"Number of aggregated searches across sources.", "operation")
+	// SearchDuration 聚合请求耗时
+	SearchDuration = Default.NewHistogramVec("meme_search_duration_seconds",
+		"Latency of aggregated searches in seconds.", DefaultBuckets, "operation")
+	// DedupMemes 去重前后的结果数，stage 为 input/output，二者之比即去重率
+	DedupMemes = Default.NewCounterVec("meme_dedup_memes_total",
+		"Number of memes before (input) and after (output) deduplication.", "stage")
+
+	// CacheRequests 结果缓存查询次数，result 为 hit/miss
+	CacheRequests = Default.NewCounterVec("meme_cache_requests_total",
+		"Number of result cache lookups.", "kind", "result")
+
+	// ToolCalls MCP 工具调用次数，outcome 为 success/error
+	ToolCalls = Default.NewCounterVec("meme_tool_calls_total",
+		"Number of MCP tool calls.", "tool", "outcome")
+	// ToolDuration MCP 工具调用耗时
+	ToolDuration = Default.NewHistogramVec("meme_tool_call_duration_seconds",
+		"Latency of MCP tool calls in seconds.", DefaultBuckets, "tool")
+)
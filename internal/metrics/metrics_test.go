@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Requests.\nSecond line with \\ backslash.", "source", "outcome")
+	duration := r.NewHistogramVec("test_duration_seconds", "Latency.", []float64{0.1, 1}, "source")
+
+	requests.Inc("pdan", "success")
+	requests.Add(2, "pdan", "success")
+	requests.Inc("斗图\"啦\"", "error")
+	requests.Inc("a\\b\nc", "error")
+	duration.Observe(0.05, "pdan")
+	duration.Observe(0.5, "pdan")
+	duration.Observe(3, "pdan")
+
+	var b strings.Builder
+	r.WriteText(&b)
+
+	want := `# HELP test_requests_total Requests.\nSecond line with \\ backslash.
+# TYPE test_requests_total counter
+test_requests_total{source="a\\b\nc",outcome="error"} 1
+test_requests_total{source="pdan",outcome="success"} 3
+test_requests_total{source="斗图\"啦\"",outcome="error"} 1
+# HELP test_duration_seconds Latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{source="pdan",le="0.1"} 1
+test_duration_seconds_bucket{source="pdan",le="1"} 2
+test_duration_seconds_bucket{source="pdan",le="+Inf"} 3
+test_duration_seconds_sum{source="pdan"} 3.55
+test_duration_seconds_count{source="pdan"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		values []string
+		extra  []string
+		want   string
+	}{
+		{"no labels", nil, nil, nil, ""},
+		{"plain", []string{"source"}, []string{"pdan"}, nil, `{source="pdan"}`},
+		// 非 ASCII 字符与制表符原样输出，不按 Go 字符串字面量转义
+		{"unicode and tab", []string{"source"}, []string{"抖音\t表情"}, nil, "{source=\"抖音\t表情\"}"},
+		{"escaped", []string{"source"}, []string{"a\"b\\c\nd"}, nil, `{source="a\"b\\c\nd"}`},
+		{"extra only", nil, nil, []string{"le", "+Inf"}, `{le="+Inf"}`},
+		{"with extra", []string{"source"}, []string{"pdan"}, []string{"le", "0.5"}, `{source="pdan",le="0.5"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLabels(tt.names, tt.values, tt.extra...); got != tt.want {
+				t.Errorf("formatLabels() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
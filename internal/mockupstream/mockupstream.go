@@ -0,0 +1,280 @@
+package mockupstream
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shadow/meme/internal/utils"
+)
+
+var logger = utils.Logger("mockupstream")
+
+// 站点名称，用于按站点配置延迟与故障
+const (
+	SiteSougou  = "sougou"
+	SiteDoutub  = "doutub"
+	SiteDouyin  = "douyin"
+	SiteQudoutu = "qudoutu"
+	SiteDoutula = "doutula"
+	SitePdan    = "pdan"
+	SiteImages  = "images"
+)
+
+// Sites 所有模拟的站点
+var Sites = []string{SiteSougou, SiteDoutub, SiteDouyin, SiteQudoutu, SiteDoutula, SitePdan, SiteImages}
+
+// FaultKind 注入的故障类型
+type FaultKind string
+
+const (
+	FaultStatus    FaultKind = "status"    // 返回指定 HTTP 状态码
+	FaultHang      FaultKind = "hang"      // 不返回，直到客户端超时断开
+	FaultMalformed FaultKind = "malformed" // 返回无法解析的响应体
+	FaultLogout    FaultKind = "logout"    // 抖音返回未登录 (status_code=8)
+)
+
+// Fault 站点故障，Rate 为触发概率 (0 或 1 表示总是触发)
+type Fault struct {
+	Kind   FaultKind
+	Status int
+	Rate   float64
+}
+
+// Options 模拟服务配置
+type Options struct {
+	Latency map[string]time.Duration // 按站点的响应延迟，键 "*" 为默认值
+	Faults  map[string]Fault         // 按站点注入的故障
+	Total   int                      // 每个关键词的结果总数，默认 30
+	Seed    int64                    // 故障概率使用的随机种子
+}
+
+// Server 模拟各表情包站点的 HTTP 服务
+// 按请求路径分发到对应站点，因此可将所有源的 <ID>_BASE_URL 指向同一个服务，也可单独替换某个站点
+type Server struct {
+	opts Options
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	hits map[string]int
+}
+
+// New 创建模拟服务
+func New(opts Options) *Server {
+	if opts.Total <= 0 {
+		opts.Total = 30
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Server{opts: opts, rand: rand.New(rand.NewSource(seed)), hits: make(map[string]int)}
+}
+
+// Hits 返回各站点收到的请求数
+func (s *Server) Hits() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hits := make(map[string]int, len(s.hits))
+	for k, v := range s.hits {
+		hits[k] = v
+	}
+	return hits
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	site, handler := s.route(r)
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.hits[site]++
+	fault, faulty := s.opts.Faults[site]
+	if faulty && fault.Rate > 0 && fault.Rate < 1 {
+		faulty = s.rand.Float64() < fault.Rate
+	}
+	s.mu.Unlock()
+
+	logger.DebugContext(r.Context(), "mock request", "site", site, "url", r.URL.String(), "fault", faulty)
+
+	if !s.wait(r, site) {
+		return
+	}
+	if faulty {
+		switch fault.Kind {
+		case FaultStatus:
+			http.Error(w, http.StatusText(fault.Status), fault.Status)
+			return
+		case FaultHang:
+			<-r.Context().Done()
+			return
+		case FaultMalformed:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, "<<<malformed response")
+			return
+		}
+	}
+	handler(w, r, faulty && fault.Kind == FaultLogout)
+}
+
+// wait 按配置延迟响应，客户端提前断开时返回 false
+func (s *Server) wait(r *http.Request, site string) bool {
+	delay, ok := s.opts.Latency[site]
+	if !ok {
+		delay = s.opts.Latency["*"]
+	}
+	if delay <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(delay):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+type siteHandler func(w http.ResponseWriter, r *http.Request, logout bool)
+
+// route 按路径识别站点
+func (s *Server) route(r *http.Request) (string, siteHandler) {
+	path := r.URL.Path
+	switch {
+	case isImagePath(path):
+		return SiteImages, s.serveImage
+	case path == "/napi/pc/searchList":
+		return SiteSougou, s.serveSougou
+	case path == "/api/bq/getBqlistByKeyword":
+		return SiteDoutub, s.serveDoutub
+	case path == "/aweme/v1/web/im/resource/emoticon/search":
+		return SiteDouyin, s.serveDouyin
+	case path == "/search/":
+		return SiteQudoutu, s.serveQudoutu
+	case path == "/search" || strings.HasPrefix(path, "/photo/list"):
+		return SiteDoutula, s.serveDoutula
+	case path == "/" || strings.HasPrefix(path, "/page/"):
+		return SitePdan, s.servePdan
+	}
+	return "", nil
+}
+
+// ============ 配置解析 ============
+
+// ParseLatency 解析延迟配置，如 "200ms" (所有站点) 或 "pdan=2s,*=100ms"
+func ParseLatency(spec string) (map[string]time.Duration, error) {
+	latency := make(map[string]time.Duration)
+	for _, part := range splitList(spec) {
+		site, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			site, raw = "*", part
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency %q: %w", part, err)
+		}
+		latency[strings.TrimSpace(site)] = d
+	}
+	return latency, nil
+}
+
+// ParseFaults 解析故障配置，如 "doutula=503,pdan=hang,sougou=malformed@0.5,douyin=logout"
+// 值为状态码或故障类型，@ 后为触发概率
+func ParseFaults(spec string) (map[string]Fault, error) {
+	faults := make(map[string]Fault)
+	for _, part := range splitList(spec) {
+		site, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid fault %q: want site=kind", part)
+		}
+
+		var fault Fault
+		if kind, rate, ok := strings.Cut(raw, "@"); ok {
+			r, err := strconv.ParseFloat(rate, 64)
+			if err != nil || r < 0 || r > 1 {
+				return nil, fmt.Errorf("invalid fault rate %q", part)
+			}
+			raw, fault.Rate = kind, r
+		}
+
+		if code, err := strconv.Atoi(raw); err == nil {
+			fault.Kind, fault.Status = FaultStatus, code
+		} else {
+			switch kind := FaultKind(raw); kind {
+			case FaultHang, FaultMalformed, FaultLogout:
+				fault.Kind = kind
+			default:
+				return nil, fmt.Errorf("invalid fault kind %q", raw)
+			}
+		}
+		faults[strings.TrimSpace(site)] = fault
+	}
+	return faults, nil
+}
+
+func splitList(spec string) []string {
+	var parts []string
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// ============ 通用工具 ============
+
+// item 一个模拟的表情包
+type item struct {
+	ID     int
+	Title  string
+	Ext    string
+	Width  int
+	Height int
+}
+
+var imageExts = []string{"gif", "png", "jpg"}
+
+// items 生成关键词对应的第 [offset, offset+count) 个结果，同一关键词每次结果相同
+func (s *Server) items(keyword string, offset, count int) []item {
+	if offset < 0 {
+		offset = 0
+	}
+	end := min(offset+count, s.opts.Total)
+	var items []item
+	for i := offset; i < end; i++ {
+		h := hash(keyword, i)
+		items = append(items, item{
+			ID:     int(h%900000) + 100000,
+			Title:  fmt.Sprintf("%s 表情 %d", keyword, i+1),
+			Ext:    imageExts[h%uint32(len(imageExts))],
+			Width:  120 + int(h%5)*40,
+			Height: 120 + int(h/5%5)*40,
+		})
+	}
+	return items
+}
+
+// imageURL 模拟的图片地址，需通过替换 Transport 或 Host 重写访问本服务
+func imageURL(host string, it item) string {
+	return fmt.Sprintf("https://%s/mock/%d.%s", host, it.ID, it.Ext)
+}
+
+func hash(keyword string, i int) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s#%d", keyword, i)
+	return h.Sum32()
+}
+
+func queryInt(r *http.Request, name string, def int) int {
+	if v, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,241 @@
+package mockupstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 页面大小与真实站点保持一致
+const (
+	douyinPageSize = 10
+	htmlPageSize   = 12
+)
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeHTML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!DOCTYPE html><html><head><meta charset=\"utf-8\"></head><body>%s</body></html>", body)
+}
+
+// ============ JSON API ============
+
+// serveSougou 模拟 pic.sogou.com/napi/pc/searchList
+func (s *Server) serveSougou(w http.ResponseWriter, r *http.Request, _ bool) {
+	keyword := r.URL.Query().Get("query")
+	var items []map[string]any
+	for _, it := range s.items(keyword, queryInt(r, "start", 0), queryInt(r, "xml_len", 48)) {
+		items = append(items, map[string]any{
+			"title":        it.Title,
+			"oriPicUrl":    imageURL("img01.sogoucdn.com", it),
+			"picUrl":       imageURL("www.example-origin.com", it),
+			"locImageLink": imageURL("i01piccdn.sogoucdn.com", it),
+			"thumbUrl":     imageURL("i01piccdn.sogoucdn.com", item{ID: it.ID, Ext: "jpg"}),
+			"width":        it.Width,
+			"height":       it.Height,
+		})
+	}
+	writeJSON(w, map[string]any{"status": 0, "info": "ok", "data": map[string]any{"items": items}})
+}
+
+// serveDoutub 模拟 api.doutub.com/api/bq/getBqlistByKeyword
+func (s *Server) serveDoutub(w http.ResponseWriter, r *http.Request, _ bool) {
+	keyword := r.URL.Query().Get("keyword")
+	pageSize := queryInt(r, "pageSize", 20)
+	page := max(queryInt(r, "curPage", 1), 1)
+
+	var rows []map[string]any
+	for _, it := range s.items(keyword, (page-1)*pageSize, pageSize) {
+		rows = append(rows, map[string]any{
+			"id":      it.ID,
+			"imgName": it.Title,
+			"path":    imageURL("image.doutub.com", it),
+		})
+	}
+	writeJSON(w, map[string]any{"code": 1, "msg": "success", "data": map[string]any{"count": s.opts.Total, "rows": rows}})
+}
+
+// serveDouyin 模拟抖音表情搜索接口：校验登录 Cookie 与 X-Bogus 签名，按 cursor 翻页
+func (s *Server) serveDouyin(w http.ResponseWriter, r *http.Request, logout bool) {
+	if logout || !strings.Contains(r.Header.Get("Cookie"), "sessionid") {
+		writeJSON(w, map[string]any{"status_code": 8, "status_msg": "用户未登录"})
+		return
+	}
+	// 未签名的请求真实接口返回空响应体
+	if r.URL.Query().Get("X-Bogus") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		return
+	}
+
+	keyword := r.URL.Query().Get("keyword")
+	cursor := queryInt(r, "cursor", 0)
+
+	var stickers []map[string]any
+	for _, it := range s.items(keyword, cursor, douyinPageSize) {
+		uri := fmt.Sprintf("tos-cn-i-0813/%d", it.ID)
+		variant := func(suffix, ext string, width, height int) map[string]any {
+			return map[string]any{
+				"uri": uri,
+				"url_list": []string{
+					fmt.Sprintf("https://p3-pc-sign.douyinpic.com/%s~%s.%s", uri, suffix, ext),
+					fmt.Sprintf("https://p9-pc-sign.douyinpic.com/%s~%s.%s", uri, suffix, ext),
+				},
+				"width":  width,
+				"height": height,
+			}
+		}
+		stickers = append(stickers, map[string]any{
+			"id":           7300000000000000000 + it.ID,
+			"id_str":       strconv.Itoa(7300000000000000000 + it.ID),
+			"display_name": "[" + it.Title + "]",
+			"author":       map[string]any{"name": "抖音表情"},
+			"origin":       variant("origin", it.Ext, it.Width, it.Height),
+			"animate_url":  variant("animate", "gif", it.Width, it.Height),
+			"static_url":   variant("static", "png", it.Width, it.Height),
+			"thumbnail":    variant("thumb", "jpg", 96, 96),
+		})
+	}
+
+	next := cursor + len(stickers)
+	writeJSON(w, map[string]any{
+		"status_code": 0,
+		"status_msg":  "",
+		"emoticon_data": map[string]any{
+			"sticker_list": stickers,
+			"has_more":     boolToInt(next < s.opts.Total),
+			"cursor":       next,
+		},
+	})
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ============ HTML 页面 ============
+
+// serveQudoutu 模拟 www.qudoutu.cn/search/?keyword=
+func (s *Server) serveQudoutu(w http.ResponseWriter, r *http.Request, _ bool) {
+	items := s.items(r.URL.Query().Get("keyword"), 0, htmlPageSize)
+	var b strings.Builder
+	b.WriteString(`<div class="item-grid"><ul>`)
+	for _, it := range items {
+		fmt.Fprintf(&b, `<li><a class="Link" href="/detail/%d.html"><img src="/uploads/mock/%d.%s" alt="%s"></a><p>%s</p></li>`,
+			it.ID, it.ID, it.Ext, html.EscapeString(it.Title), html.EscapeString(it.Title))
+	}
+	b.WriteString(`</ul></div>`)
+	if len(items) == 0 {
+		b.WriteString(`<div class="empty">没有找到相关表情</div>`)
+	}
+	writeHTML(w, b.String())
+}
+
+// serveDoutula 模拟 www.doutupk.com 的搜索页与最新列表页
+func (s *Server) serveDoutula(w http.ResponseWriter, r *http.Request, _ bool) {
+	keyword := r.URL.Query().Get("keyword")
+	if r.URL.Path != "/search" {
+		keyword = "最新"
+	}
+	page := max(queryInt(r, "page", 1), 1)
+
+	items := s.items(keyword, (page-1)*htmlPageSize, htmlPageSize)
+	var b strings.Builder
+	b.WriteString(`<div class="random_picture"><div class="page-content text-center">`)
+	if len(items) == 0 {
+		b.WriteString(`<p>没有找到相关表情</p>`)
+	}
+	for _, it := range items {
+		title := html.EscapeString(it.Title)
+		fmt.Fprintf(&b, `<a class="col-xs-6 col-md-2" href="/photo/%d"><img src="https://www.doutupk.com/static/common/loading.gif" data-original="%s" data-backup="%s" alt="%s" class="img-responsive lazy image_dtb"><p style="display: none">%s</p></a>`,
+			it.ID, imageURL("img.doutupk.com", it), imageURL("ws1.sinaimg.cn", it), title, title)
+	}
+	b.WriteString(`</div></div>`)
+	writeHTML(w, b.String())
+}
+
+// servePdan 模拟 pdan.com.cn 的搜索页 (?s=) 与分页列表
+func (s *Server) servePdan(w http.ResponseWriter, r *http.Request, _ bool) {
+	keyword := r.URL.Query().Get("s")
+	page := 1
+	if strings.HasPrefix(r.URL.Path, "/page/") {
+		page, _ = strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/page/"), "/"))
+		page = max(page, 1)
+		keyword = "最新"
+	} else if keyword == "" {
+		keyword = "最新"
+	}
+
+	items := s.items(keyword, (page-1)*htmlPageSize, htmlPageSize)
+	var b strings.Builder
+	b.WriteString(`<main class="site-main"><div class="row">`)
+	if len(items) == 0 {
+		b.WriteString(`<section class="no-results not-found"><h1>Nothing Found</h1></section>`)
+	}
+	for _, it := range items {
+		fmt.Fprintf(&b, `<div class="col"><a class="imageLink image loading" href="/%d.html" title="%s"><img class="lazyload" src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="%s"></a></div>`,
+			it.ID, html.EscapeString(it.Title), imageURL("pdan.com.cn", it))
+	}
+	b.WriteString(`</div></main>`)
+	writeHTML(w, b.String())
+}
+
+// ============ 图片 ============
+
+func isImagePath(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".gif", ".png", ".jpg", ".jpeg", ".webp":
+		return true
+	}
+	return false
+}
+
+// serveImage 按扩展名生成纯色图片，颜色由路径决定 (webp 无法编码，以 png 返回)
+func (s *Server) serveImage(w http.ResponseWriter, r *http.Request, _ bool) {
+	h := hash(r.URL.Path, 0)
+	fill := color.RGBA{R: uint8(h), G: uint8(h >> 8), B: uint8(h >> 16), A: 255}
+	rect := image.Rect(0, 0, 64, 64)
+
+	var buf bytes.Buffer
+	var err error
+	switch strings.ToLower(path.Ext(r.URL.Path)) {
+	case ".gif":
+		img := image.NewPaletted(rect, palette.Plan9)
+		draw.Draw(img, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+		err = gif.Encode(&buf, img, nil)
+		w.Header().Set("Content-Type", "image/gif")
+	case ".jpg", ".jpeg":
+		img := image.NewRGBA(rect)
+		draw.Draw(img, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+		err = jpeg.Encode(&buf, img, nil)
+		w.Header().Set("Content-Type", "image/jpeg")
+	default:
+		img := image.NewRGBA(rect)
+		draw.Draw(img, rect, image.NewUniform(fill), image.Point{}, draw.Src)
+		err = png.Encode(&buf, img)
+		w.Header().Set("Content-Type", "image/png")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,59 @@
+// Package redact 脱敏日志、链路追踪与录制文件中的敏感信息 (Cookie、Token、URL 中的凭据等)
+package redact
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Placeholder 脱敏后的占位符
+const Placeholder = "[REDACTED]"
+
+// sensitiveKeyParts 键名包含这些片段时视为敏感 (不区分大小写)
+var sensitiveKeyParts = []string{"cookie", "token", "secret", "password", "authorization", "session", "sid_", "signature", "api_key", "apikey"}
+
+// IsSensitiveKey 判断日志字段、请求头或 URL 参数名是否敏感
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// URL 将 URL 中敏感的查询参数与用户信息替换为占位符，无法解析时原样返回
+func URL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.RawQuery == "" && u.User == nil) {
+		return raw
+	}
+	if u.User != nil {
+		u.User = url.User(Placeholder)
+	}
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if IsSensitiveKey(key) {
+			query.Set(key, Placeholder)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// urlPattern 匹配文本中内嵌的 URL，如 *url.Error 的 Get "https://...": ...
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s"'<>]+`)
+
+// Text 对文本 (如错误信息) 中出现的每个 URL 做脱敏，其余内容原样保留
+func Text(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlPattern.ReplaceAllStringFunc(s, URL)
+}
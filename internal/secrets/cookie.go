@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpiryWarningWindow Cookie 距过期不足该时长时提示即将过期
+const ExpiryWarningWindow = 7 * 24 * time.Hour
+
+// CookieState Cookie 的过期状态
+type CookieState string
+
+const (
+	CookieUnknown  CookieState = "unknown"  // 无法从 Cookie 中推断过期时间
+	CookieValid    CookieState = "valid"    // 未过期
+	CookieExpiring CookieState = "expiring" // 将在 ExpiryWarningWindow 内过期
+	CookieExpired  CookieState = "expired"  // 已过期
+)
+
+// CookieStatus Cookie 过期检查结果
+type CookieStatus struct {
+	State   CookieState `json:"state"`
+	Expires time.Time   `json:"expires,omitempty"`
+}
+
+// ParseCookie 解析 Cookie 请求头 (a=1; b=2) 为键值对，重复键取第一个
+func ParseCookie(cookie string) map[string]string {
+	pairs := make(map[string]string)
+	for _, part := range strings.Split(cookie, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		if _, exists := pairs[name]; !exists {
+			pairs[name] = value
+		}
+	}
+	return pairs
+}
+
+// CookieExpiry 从 Cookie 中推断登录态的过期时间
+// 抖音等字节系站点的 sid_guard 形如 <sid>|<签发时间戳>|<有效秒数>|<过期日期>
+func CookieExpiry(cookie string) (time.Time, bool) {
+	guard, ok := ParseCookie(cookie)["sid_guard"]
+	if !ok {
+		return time.Time{}, false
+	}
+	if unescaped, err := url.QueryUnescape(guard); err == nil {
+		guard = unescaped
+	}
+
+	parts := strings.Split(guard, "|")
+	if len(parts) < 3 {
+		return time.Time{}, false
+	}
+	issued, err1 := strconv.ParseInt(parts[1], 10, 64)
+	maxAge, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 == nil && err2 == nil && issued > 0 && maxAge > 0 {
+		return time.Unix(issued+maxAge, 0), true
+	}
+
+	if len(parts) >= 4 {
+		if t, err := time.Parse("Mon, 02-Jan-2006 15:04:05 MST", strings.ReplaceAll(parts[3], "+", " ")); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// CheckCookie 检查 Cookie 在 now 时刻的过期状态
+func CheckCookie(cookie string, now time.Time) CookieStatus {
+	expires, ok := CookieExpiry(cookie)
+	if !ok {
+		return CookieStatus{State: CookieUnknown}
+	}
+	status := CookieStatus{State: CookieValid, Expires: expires}
+	switch {
+	case !now.Before(expires):
+		status.State = CookieExpired
+	case expires.Sub(now) < ExpiryWarningWindow:
+		status.State = CookieExpiring
+	}
+	return status
+}
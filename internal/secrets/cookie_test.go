@@ -0,0 +1,58 @@
+package secrets
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCheckCookie(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	issued := now.Add(-24 * time.Hour).Unix()
+
+	tests := []struct {
+		name        string
+		cookie      string
+		wantState   CookieState
+		wantExpires time.Time
+	}{
+		{"no sid_guard", "sessionid=abc", CookieUnknown, time.Time{}},
+		{"malformed sid_guard", "sid_guard=abc|x", CookieUnknown, time.Time{}},
+		{
+			"valid",
+			"sessionid=abc; sid_guard=abc%7C" + strconv.FormatInt(issued, 10) + "%7C5184000%7CWed%2C+31-Jul-2024+00%3A00%3A00+GMT",
+			CookieValid, time.Unix(issued+5184000, 0),
+		},
+		{
+			"expiring within a week",
+			"sid_guard=abc|" + strconv.FormatInt(issued, 10) + "|259200|ignored",
+			CookieExpiring, time.Unix(issued+259200, 0),
+		},
+		{
+			"expired",
+			"sid_guard=abc|" + strconv.FormatInt(issued, 10) + "|3600|ignored",
+			CookieExpired, time.Unix(issued+3600, 0),
+		},
+		{
+			// 签发时间缺失时回退到过期日期
+			"date only",
+			"sid_guard=abc|0|0|Sat, 01-Jun-2024 12:00:00 GMT",
+			CookieExpiring, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckCookie(tt.cookie, now)
+			if got.State != tt.wantState || !got.Expires.Equal(tt.wantExpires) {
+				t.Errorf("CheckCookie() = %+v, want %s expiring %v", got, tt.wantState, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestParseCookie(t *testing.T) {
+	got := ParseCookie(" sessionid=a; sid_tt=b ;sessionid=c; broken; =x")
+	if len(got) != 2 || got["sessionid"] != "a" || got["sid_tt"] != "b" {
+		t.Errorf("ParseCookie() = %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Origin 秘密值的来源
+type Origin string
+
+const (
+	OriginNone  Origin = ""
+	OriginEnv   Origin = "env"   // 环境变量 NAME
+	OriginFile  Origin = "file"  // 环境变量 NAME_FILE 指向的文件
+	OriginStore Origin = "store" // 加密存储
+)
+
+// Load 按 环境变量 NAME → NAME_FILE 指向的文件 → 加密存储 的顺序读取秘密
+// 都未配置时返回空 Secret 与 OriginNone；加密存储仅在设置了 MEME_SECRETS_KEY 时读取
+func Load(name string) (Secret, Origin, error) {
+	if value := os.Getenv(name); value != "" {
+		return New(value), OriginEnv, nil
+	}
+
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		value, err := ReadFile(path)
+		if err != nil {
+			return Secret{}, OriginNone, err
+		}
+		return value, OriginFile, nil
+	}
+
+	if passphrase := os.Getenv(StoreKeyEnv); passphrase != "" {
+		path := DefaultStorePath()
+		if _, err := os.Stat(path); err != nil {
+			return Secret{}, OriginNone, nil
+		}
+		store, err := OpenStore(path, passphrase)
+		if err != nil {
+			return Secret{}, OriginNone, err
+		}
+		if value, ok := store.Get(name); ok {
+			return value, OriginStore, nil
+		}
+	}
+
+	return Secret{}, OriginNone, nil
+}
+
+// ReadFile 读取秘密文件并去除首尾空白，文件对其他用户可读时返回错误
+func ReadFile(path string) (Secret, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Secret{}, fmt.Errorf("read secret file failed: %w", err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		return Secret{}, fmt.Errorf("secret file %s is accessible by other users (mode %04o), run: chmod 600 %s", path, perm, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Secret{}, fmt.Errorf("read secret file failed: %w", err)
+	}
+	return New(strings.TrimSpace(string(data))), nil
+}
@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shadow/meme/internal/redact"
+)
+
+// Secret 敏感字符串 (Cookie、Token、代理密码等)
+// 格式化输出、JSON 序列化与日志中只显示掩码，需要原文时调用 Reveal
+// 反序列化 (JSON、YAML 等文本格式) 读取原文，便于从配置文件加载
+type Secret struct {
+	value string
+}
+
+// New 包装敏感字符串
+func New(value string) Secret {
+	return Secret{value: value}
+}
+
+// Reveal 返回原文，仅在真正发送请求时使用
+func (s Secret) Reveal() string { return s.value }
+
+// IsZero 是否为空
+func (s Secret) IsZero() bool { return s.value == "" }
+
+func (s Secret) String() string   { return Mask(s.value) }
+func (s Secret) GoString() string { return fmt.Sprintf("secrets.Secret(%q)", Mask(s.value)) }
+
+// Format 保证 %v、%+v、%#v、%s 等任意动词都不会输出原文
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, s.GoString())
+		return
+	}
+	io.WriteString(f, s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON 从 JSON 字符串读取原文
+func (s *Secret) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("secret must be a string: %w", err)
+	}
+	s.value = value
+	return nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，供 YAML 等格式读取原文
+func (s *Secret) UnmarshalText(text []byte) error {
+	s.value = string(text)
+	return nil
+}
+
+// LogValue 实现 slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Mask 返回掩码形式：保留前 4 个字符并标注长度，短值完全隐藏
+func Mask(value string) string {
+	n := utf8.RuneCountInString(value)
+	switch {
+	case n == 0:
+		return ""
+	case n <= 8:
+		return "****"
+	}
+	return fmt.Sprintf("%s****(%d chars)", string([]rune(value)[:4]), n)
+}
+
+// MaskURL 隐藏 URL 中的用户信息与敏感查询参数 (token、key 等)，用于展示代理地址等配置；无法解析时整体掩码
+// 有密码时保留用户名，只有用户名时 (常见于 https://<token>@host) 整体掩码
+func MaskURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Mask(raw)
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "****")
+		} else {
+			u.User = url.User("****")
+		}
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		changed := false
+		for key := range query {
+			if redact.IsSensitiveKey(key) || isCredentialParam(key) {
+				query.Set(key, "****")
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	return u.String()
+}
+
+// isCredentialParam 代理与网关地址中常见的凭据参数名 (redact.IsSensitiveKey 之外的补充)
+func isCredentialParam(key string) bool {
+	switch strings.ToLower(key) {
+	case "key", "auth", "pass", "passwd", "pwd", "sign", "sig", "credential", "credentials", "access_key", "accesskey":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 加密存储参数
+const (
+	storeVersion    = 1
+	kdfName         = "pbkdf2-sha256"
+	kdfIterations   = 200000
+	maxIterations   = 10000000 // 读取文件时允许的最大迭代次数，防止被篡改的文件让解锁无限期卡住
+	keySize         = 32       // AES-256
+	saltSize        = 16
+	StoreKeyEnv     = "MEME_SECRETS_KEY"   // 解锁加密存储的口令
+	StorePathEnv    = "MEME_SECRETS_STORE" // 加密存储路径
+	defaultStoreDir = "meme"
+)
+
+// ErrWrongPassphrase 口令错误或文件被篡改
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted secrets store")
+
+// storeFile 加密存储的文件格式，data 为 AES-GCM 加密的 JSON 对象
+type storeFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Store 口令加密的本地秘密存储 (类似系统钥匙串)
+type Store struct {
+	path       string
+	passphrase string
+	values     map[string]string
+}
+
+// DefaultStorePath 返回加密存储路径，优先使用 MEME_SECRETS_STORE
+func DefaultStorePath() string {
+	if path := os.Getenv(StorePathEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", "secrets.json")
+	}
+	return filepath.Join(dir, defaultStoreDir, "secrets.json")
+}
+
+// OpenStore 打开加密存储，文件不存在时返回空存储
+func OpenStore(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("secrets store requires %s", StoreKeyEnv)
+	}
+
+	store := &Store{path: path, passphrase: passphrase, values: make(map[string]string)}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secrets store failed: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse secrets store failed: %w", err)
+	}
+	if file.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported secrets store version: %d", file.Version)
+	}
+	if file.KDF != kdfName {
+		return nil, fmt.Errorf("unsupported secrets store kdf: %q", file.KDF)
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if err := json.Unmarshal(plain, &store.values); err != nil {
+		return nil, fmt.Errorf("parse secrets failed: %w", err)
+	}
+	return store, nil
+}
+
+// Path 存储文件路径
+func (s *Store) Path() string { return s.path }
+
+// Get 读取秘密
+func (s *Store) Get(name string) (Secret, bool) {
+	value, ok := s.values[name]
+	return New(value), ok
+}
+
+// Set 写入秘密，需调用 Save 持久化
+func (s *Store) Set(name, value string) {
+	s.values[name] = value
+}
+
+// Delete 删除秘密，需调用 Save 持久化
+func (s *Store) Delete(name string) bool {
+	_, ok := s.values[name]
+	delete(s.values, name)
+	return ok
+}
+
+// Names 返回已保存的名称 (有序)
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save 重新加密并原子写入文件 (权限 0600)
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return fmt.Errorf("encode secrets failed: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate salt failed: %w", err)
+	}
+	gcm, err := newGCM(s.passphrase, salt, kdfIterations)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate nonce failed: %w", err)
+	}
+
+	data, err := json.MarshalIndent(storeFile{
+		Version:    storeVersion,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode secrets store failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create secrets dir failed: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write secrets store failed: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write secrets store failed: %w", err)
+	}
+	return nil
+}
+
+// newGCM 由口令派生密钥并创建 AES-GCM
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || iterations > maxIterations {
+		return nil, fmt.Errorf("invalid kdf iterations: %d", iterations)
+	}
+	block, err := aes.NewCipher(deriveKey(passphrase, salt, iterations, keySize))
+	if err != nil {
+		return nil, fmt.Errorf("create cipher failed: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey PBKDF2-HMAC-SHA256 (RFC 8018) 派生密钥
+func deriveKey(passphrase string, salt []byte, iterations, keyLen int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, keyLen, sha256.New)
+}
@@ -0,0 +1,166 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// PBKDF2-HMAC-SHA256 测试向量，来自 RFC 7914 第 11 节
+func TestDeriveKeyRFC7914(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(deriveKey(tt.passphrase, []byte(tt.salt), tt.iterations, 64))
+		if got != tt.want {
+			t.Errorf("deriveKey(%q, %q, %d) = %s, want %s", tt.passphrase, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+// saveStore 写入只含一个秘密的存储并返回文件路径
+func saveStore(t *testing.T, passphrase string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	store, err := OpenStore(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("DOUYIN_COOKIE", "sessionid=abc123; sid_tt=abc123")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rewriteStore 修改存储文件中的字段后写回
+func rewriteStore(t *testing.T, path string, modify func(*storeFile)) {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		t.Fatal(err)
+	}
+	modify(&file)
+	raw, err = json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := saveStore(t, "correct horse")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("store mode = %04o, want 0600", perm)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "abc123") {
+		t.Errorf("store file contains plaintext: %s", raw)
+	}
+
+	store, err := OpenStore(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, ok := store.Get("DOUYIN_COOKIE")
+	if !ok || value.Reveal() != "sessionid=abc123; sid_tt=abc123" {
+		t.Errorf("Get() = %q, %v", value.Reveal(), ok)
+	}
+	if names := store.Names(); len(names) != 1 || names[0] != "DOUYIN_COOKIE" {
+		t.Errorf("Names() = %v", names)
+	}
+}
+
+func TestOpenStoreErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase string
+		modify     func(*storeFile)
+		wantIs     error
+		wantErr    string
+	}{
+		{name: "wrong passphrase", passphrase: "wrong", wantIs: ErrWrongPassphrase},
+		{
+			name:       "tampered ciphertext",
+			passphrase: "correct horse",
+			modify:     func(f *storeFile) { f.Data[0] ^= 0xff },
+			wantIs:     ErrWrongPassphrase,
+		},
+		{
+			name:       "tampered salt",
+			passphrase: "correct horse",
+			modify:     func(f *storeFile) { f.Salt[0] ^= 0xff },
+			wantIs:     ErrWrongPassphrase,
+		},
+		{
+			name:       "iterations too large",
+			passphrase: "correct horse",
+			modify:     func(f *storeFile) { f.Iterations = maxIterations + 1 },
+			wantErr:    "invalid kdf iterations",
+		},
+		{
+			name:       "iterations zero",
+			passphrase: "correct horse",
+			modify:     func(f *storeFile) { f.Iterations = 0 },
+			wantErr:    "invalid kdf iterations",
+		},
+		{
+			name:       "unknown kdf",
+			passphrase: "correct horse",
+			modify:     func(f *storeFile) { f.KDF = "scrypt" },
+			wantErr:    "unsupported secrets store kdf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := saveStore(t, "correct horse")
+			if tt.modify != nil {
+				rewriteStore(t, path, tt.modify)
+			}
+			_, err := OpenStore(path, tt.passphrase)
+			switch {
+			case err == nil:
+				t.Fatal("OpenStore() succeeded, want error")
+			case tt.wantIs != nil && !errors.Is(err, tt.wantIs):
+				t.Errorf("OpenStore() error = %v, want %v", err, tt.wantIs)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("OpenStore() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenStoreMissingFile(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "none.json"), "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Names()) != 0 {
+		t.Errorf("Names() = %v, want empty", store.Names())
+	}
+	if _, err := OpenStore(filepath.Join(t.TempDir(), "none.json"), ""); err == nil {
+		t.Error("OpenStore() without passphrase succeeded")
+	}
+}
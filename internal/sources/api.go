@@ -1,16 +1,22 @@
 package sources
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/shadow/meme/internal/browser"
 	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/douyinsign"
+	"github.com/shadow/meme/internal/secrets"
 )
 
 // ============ 搜狗表情 (Sougou) ============
@@ -27,6 +33,18 @@ func NewSougou() *SougouSource {
 			description: "从搜狗图片搜索表情包 (JSON API)",
 			requireAuth: false,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://pic.sogou.com",
+				Referer: "https://pic.sogou.com/pics",
+			},
+			capabilities: core.Capabilities{
+				SupportsPagination: true,
+				MaxPageSize:        48,
+				HonorsLimit:        true,
+				ProvidesDimensions: true,
+				Languages:          []string{"zh"},
+				Formats:            []string{"jpg", "gif", "png"},
+			},
 		},
 	}
 }
@@ -70,64 +88,47 @@ func (s *SougouSource) Search(ctx context.Context, keyword string, opts core.Sea
 		"scene":    {"pic_result"},
 	}
 
-	apiURL := "https://pic.sogou.com/napi/pc/searchList?" + params.Encode()
-	fmt.Fprintf(os.Stderr, "🌐 [Request] GET %s\n", apiURL)
-
+	apiURL := s.endpoint.url("/napi/pc/searchList?" + params.Encode())
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
-	// 设置完整的浏览器 Headers
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+	// 按浏览器配置设置 Headers，页面内的 XHR 请求
+	s.applyHeaders(req, s.profile(), browser.KindAPI)
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Sec-Fetch-Dest", "empty")
-	req.Header.Set("Sec-Fetch-Mode", "cors")
-	req.Header.Set("Sec-Fetch-Site", "same-origin")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36")
 	req.Header.Set("X-Time4p", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	req.Header.Set("sec-ch-ua", `"Google Chrome";v="143", "Chromium";v="143", "Not A(Brand";v="24"`)
-	req.Header.Set("sec-ch-ua-mobile", "?0")
-	req.Header.Set("sec-ch-ua-platform", `"macOS"`)
-	req.Header.Set("Referer", "https://pic.sogou.com/pics")
+	req.Header.Set("Referer", s.endpoint.Referer)
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", core.ErrRequestFailed, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &core.StatusError{Code: resp.StatusCode}
 	}
 
 	var data sougouResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := decodeJSON(ctx, resp.Body, &data); err != nil {
+		return nil, err
 	}
 
 	var memes []core.Meme
 	for _, item := range data.Data.Items {
-		// 优先使用原始图片链接，其次 CDN 链接，最后缩略图
-		imgURL := item.OriPicUrl
-		if imgURL == "" {
-			imgURL = item.PicUrl
-		}
-		if imgURL == "" {
-			imgURL = item.LocImageLink
-		}
-		if imgURL == "" {
-			imgURL = item.ThumbUrl
-		}
-		if imgURL == "" {
+		// 优先使用原始图片链接，其次 CDN 链接，最后缩略图；其余有效链接作为备用
+		urls := core.CollectImageURLs(item.OriPicUrl, item.PicUrl, item.LocImageLink, item.ThumbUrl)
+		if len(urls) == 0 {
 			continue
 		}
+		imgURL := urls[0]
 
-		imgURL = core.NormalizeURL(imgURL)
-		if !core.IsValidImageURL(imgURL) {
-			continue
+		// 缩略图优先使用搜狗 CDN 的缩略图
+		thumbnail := imgURL
+		if thumbs := core.CollectImageURLs(item.ThumbUrl, item.LocImageLink); len(thumbs) > 0 {
+			thumbnail = thumbs[0]
 		}
 
 		title := item.Title
@@ -136,12 +137,14 @@ func (s *SougouSource) Search(ctx context.Context, keyword string, opts core.Sea
 		}
 
 		memes = append(memes, core.Meme{
-			Title:    title,
-			URL:      imgURL,
-			Platform: s.id,
-			Format:   core.DetectImageFormat(imgURL),
-			Width:    item.Width,
-			Height:   item.Height,
+			Title:      title,
+			URL:        imgURL,
+			Platform:   s.id,
+			Format:     core.DetectImageFormat(imgURL),
+			Width:      item.Width,
+			Height:     item.Height,
+			Thumbnail:  thumbnail,
+			Alternates: urls[1:],
 		})
 	}
 
@@ -166,6 +169,18 @@ func NewDoutub() *DoutubSource {
 			description: "从 api.doutub.com 搜索表情包",
 			requireAuth: false,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://api.doutub.com",
+				Referer: "https://www.doutub.com/",
+			},
+			capabilities: core.Capabilities{
+				SupportsPagination: true,
+				MaxPageSize:        50,
+				HonorsLimit:        true,
+				NeedsProxy:         true,
+				Languages:          []string{"zh"},
+				Formats:            []string{"gif", "jpg", "png"},
+			},
 		},
 	}
 }
@@ -204,38 +219,31 @@ func (s *DoutubSource) Search(ctx context.Context, keyword string, opts core.Sea
 		"pageSize": {fmt.Sprintf("%d", limit)},
 	}
 
-	apiURL := "https://api.doutub.com/api/bq/getBqlistByKeyword?" + params.Encode()
-	fmt.Fprintf(os.Stderr, "🌐 [Request] GET %s\n", apiURL)
-
+	apiURL := s.endpoint.url("/api/bq/getBqlistByKeyword?" + params.Encode())
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
-	// 模拟浏览器 Headers
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "application/json, text/plain, */*")
-	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	req.Header.Set("Origin", "https://www.doutub.com")
-	req.Header.Set("Referer", "https://www.doutub.com/")
-	req.Header.Set("Sec-Fetch-Dest", "empty")
-	req.Header.Set("Sec-Fetch-Mode", "cors")
+	// 模拟浏览器 Headers，接口与官网为同站跨域
+	s.applyHeaders(req, s.profile(), browser.KindAPI)
+	req.Header.Set("Origin", s.endpoint.origin())
+	req.Header.Set("Referer", s.endpoint.Referer)
 	req.Header.Set("Sec-Fetch-Site", "same-site")
-	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", core.ErrRequestFailed, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &core.StatusError{Code: resp.StatusCode}
 	}
 
 	var data doutubResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := decodeJSON(ctx, resp.Body, &data); err != nil {
+		return nil, err
 	}
 
 	if data.Code != 1 {
@@ -253,8 +261,8 @@ func (s *DoutubSource) Search(ctx context.Context, keyword string, opts core.Sea
 			continue
 		}
 
-		// 使用 applyImageProxy 处理防盗链，Referer 设为官网
-		finalURL := applyImageProxy(imgURL, "https://www.doutub.com/")
+		// 使用图片代理处理防盗链，Referer 设为官网
+		finalURL, alternates := proxiedImage(imgURL, s.endpoint.Referer)
 
 		title := item.ImgName
 		if title == "" {
@@ -262,10 +270,12 @@ func (s *DoutubSource) Search(ctx context.Context, keyword string, opts core.Sea
 		}
 
 		memes = append(memes, core.Meme{
-			Title:    title,
-			URL:      finalURL,
-			Platform: s.id,
-			Format:   core.DetectImageFormat(imgURL),
+			Title:      title,
+			URL:        finalURL,
+			Platform:   s.id,
+			Format:     core.DetectImageFormat(imgURL),
+			Thumbnail:  finalURL,
+			Alternates: alternates,
 		})
 	}
 
@@ -276,10 +286,26 @@ func (s *DoutubSource) Search(ctx context.Context, keyword string, opts core.Sea
 
 type DouyinSource struct {
 	BaseSource
-	cookie string
+	cookies *CookiePool
+	signer  *douyinsign.Signer
+	cursors *cursorCache
 }
 
+// douyinSearchPath 抖音表情搜索接口路径
+const douyinSearchPath = "/aweme/v1/web/im/resource/emoticon/search"
+
+// douyinCheckKeyword 检查 Cookie 时使用的搜索关键词
+const douyinCheckKeyword = "表情"
+
 func NewDouyin(cookie string) *DouyinSource {
+	return NewDouyinWithPool(NewCookiePool([]secrets.Secret{secrets.New(cookie)}, RotationFailover))
+}
+
+// NewDouyinWithPool 使用 Cookie 池创建抖音源，认证失败时自动切换 Cookie
+func NewDouyinWithPool(cookies *CookiePool) *DouyinSource {
+	if cookies == nil {
+		cookies = NewCookiePool(nil, RotationFailover)
+	}
 	return &DouyinSource{
 		BaseSource: BaseSource{
 			id:          "douyin",
@@ -287,111 +313,348 @@ func NewDouyin(cookie string) *DouyinSource {
 			description: "从抖音搜索热门表情包 (需要 Cookie)",
 			requireAuth: true,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://www.douyin.com",
+				Referer: "https://www.douyin.com/",
+			},
+			capabilities: core.Capabilities{
+				SupportsPagination: true,
+				MaxPageSize:        10,
+				HonorsLimit:        true,
+				ProvidesDimensions: true,
+				Languages:          []string{"zh"},
+				Formats:            []string{"gif", "webp", "png"},
+				RateLimitPerMinute: 30,
+			},
 		},
-		cookie: cookie,
+		cookies: cookies,
+		signer:  douyinsign.NewSigner(""),
+		cursors: newCursorCache(),
 	}
 }
 
-// SetCookie 动态设置 Cookie
+// SetSession 抖音的 Cookie 由 Cookie 池管理 (按账号轮换)，不使用会话 Cookie Jar 与预热
+func (s *DouyinSource) SetSession(SessionConfig) error {
+	return nil
+}
+
+// SetCookie 动态设置 Cookie (替换池中的全部 Cookie)，可与 Search 并发调用
 func (s *DouyinSource) SetCookie(cookie string) {
-	s.cookie = cookie
+	s.cookies.Replace([]secrets.Secret{secrets.New(cookie)})
+}
+
+// Cookies 返回 Cookie 池，用于查看各 Cookie 状态
+func (s *DouyinSource) Cookies() *CookiePool {
+	return s.cookies
 }
 
 // douyinResponse 抖音 API 响应结构
+// 未登录或 Cookie 失效时接口仍返回 200，需结合 status_code 与 emoticon_data 判断
 type douyinResponse struct {
-	EmoticonData struct {
-		StickerList []struct {
-			Author struct {
-				Name string `json:"name"`
-			} `json:"author"`
-			Origin struct {
-				URLList []string `json:"url_list"`
-			} `json:"origin"`
-		} `json:"sticker_list"`
+	StatusCode   int    `json:"status_code"`
+	StatusMsg    string `json:"status_msg"`
+	EmoticonData *struct {
+		StickerList []douyinSticker `json:"sticker_list"`
+		HasMore     douyinFlag      `json:"has_more"`
+		Cursor      json.Number     `json:"cursor"`
 	} `json:"emoticon_data"`
 }
 
+// douyinSticker 单个贴纸，origin 为原图，animate_url/static_url 分别为动图与静态首帧
+type douyinSticker struct {
+	ID          json.Number `json:"id"`
+	IDStr       string      `json:"id_str"`
+	DisplayName string      `json:"display_name"`
+	Description string      `json:"description"`
+	Author      struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	Origin     douyinImage `json:"origin"`
+	AnimateURL douyinImage `json:"animate_url"`
+	StaticURL  douyinImage `json:"static_url"`
+	Thumbnail  douyinImage `json:"thumbnail"`
+}
+
+// douyinImage 同一张图片的多个 CDN 镜像地址及尺寸
+type douyinImage struct {
+	URI     string   `json:"uri"`
+	URLList []string `json:"url_list"`
+	Width   int      `json:"width"`
+	Height  int      `json:"height"`
+}
+
+// douyinFlag 兼容接口中以 0/1 或 true/false 表示的布尔字段
+type douyinFlag bool
+
+func (f *douyinFlag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1", `"1"`:
+		*f = true
+	default:
+		*f = false
+	}
+	return nil
+}
+
+// douyinPage 一页贴纸及翻页信息
+type douyinPage struct {
+	memes   []core.Meme
+	cursor  int64 // 下一页的 cursor
+	hasMore bool
+}
+
 func (s *DouyinSource) Search(ctx context.Context, keyword string, opts core.SearchOptions) ([]core.Meme, error) {
-	if s.cookie == "" {
-		return nil, fmt.Errorf("douyin source requires cookie configuration")
+	// 其余 Web 公共参数与签名由 signer 在发送时补全
+	params := url.Values{
+		"keyword": {keyword},
 	}
 
+	return s.fetchPage(ctx, s.endpoint.url(douyinSearchPath), params, opts)
+}
+
+// maxCursorWalk 未知 cursor 时最多从最近的已知页向后连续请求的页数，超出时直接返回错误
+// 页码来自调用方参数，不加限制时一个大页码会触发大量签名请求
+const maxCursorWalk = 5
+
+// fetchPage 按接口返回的 cursor 翻页：第 N 页使用第 N-1 页响应中的 cursor
+// 未记录过上一页的 cursor 时，从已知的最近一页开始依次请求 (最多 maxCursorWalk 页)；has_more 为假后不再请求
+func (s *DouyinSource) fetchPage(ctx context.Context, baseURL string, params url.Values, opts core.SearchOptions) ([]core.Meme, error) {
 	page := opts.Page
 	if page < 1 {
 		page = 1
 	}
-	cursor := (page - 1) * 10
 
+	key := baseURL + "?" + params.Encode()
+	current, cursor, ok := s.cursors.nearest(key, page)
+	if ok && page-current > maxCursorWalk {
+		return nil, fmt.Errorf("page %d is too far from the nearest known cursor (page %d), request at most %d pages ahead", page, current, maxCursorWalk)
+	}
+	for {
+		if !ok {
+			// 上一页已经是最后一页
+			return nil, nil
+		}
+
+		pageParams := url.Values{"cursor": {strconv.FormatInt(cursor, 10)}}
+		for k, v := range params {
+			pageParams[k] = v
+		}
+		result, err := s.fetchStickers(ctx, baseURL, pageParams, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.cursors.record(key, current+1, result.cursor, result.hasMore)
+
+		if current == page {
+			return result.memes, nil
+		}
+		current, cursor, ok = current+1, result.cursor, result.hasMore
+	}
+}
+
+// fetchStickers 依次使用 Cookie 池中可用的 Cookie 请求，认证失败时禁用该 Cookie 并切换下一个
+func (s *DouyinSource) fetchStickers(ctx context.Context, baseURL string, params url.Values, opts core.SearchOptions) (*douyinPage, error) {
+	if s.cookies.Len() == 0 {
+		return nil, &core.AuthError{Source: s.id, Reason: "no cookie configured"}
+	}
+
+	var lastErr error
+	for i := 0; i < s.cookies.Len(); i++ {
+		entry, ok := s.cookies.acquire(time.Now())
+		if !ok {
+			break
+		}
+
+		result, err := s.fetchWithCookie(ctx, baseURL, params, entry.cookie, opts)
+		if err == nil {
+			s.cookies.markHealthy(entry)
+			return result, nil
+		}
+
+		var authErr *core.AuthError
+		if !errors.As(err, &authErr) {
+			return nil, err
+		}
+		logger.WarnContext(ctx, "douyin cookie rejected, rotating", "slot", entry.index, "reason", authErr.Reason)
+		s.cookies.markFailed(entry, authErr.Reason)
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, &core.AuthError{Source: s.id, Reason: "all cookies are expired or invalid"}
+}
+
+// CheckCookie 使用指定 Cookie 请求一次搜索接口，返回 nil 表示 Cookie 可用
+// 认证失败时返回 *core.AuthError，网络等其他问题返回普通错误
+func (s *DouyinSource) CheckCookie(ctx context.Context, cookie secrets.Secret) error {
+	if reason := localCookieProblem(cookie, time.Now()); reason != "" {
+		return &core.AuthError{Source: s.id, Reason: reason}
+	}
 	params := url.Values{
-		"device_platform": {"webapp"},
-		"aid":             {"1128"},
-		"keyword":         {keyword},
-		"cursor":          {fmt.Sprintf("%d", cursor)},
+		"keyword": {douyinCheckKeyword},
+		"cursor":  {"0"},
 	}
+	_, err := s.fetchWithCookie(ctx, s.endpoint.url(douyinSearchPath), params, cookie, core.SearchOptions{Limit: 1})
+	return err
+}
 
-	apiURL := "https://www.douyin.com/aweme/v1/web/im/resource/emoticon/search?" + params.Encode()
-	fmt.Fprintf(os.Stderr, "🌐 [Request] GET %s\n", apiURL)
+// fetchWithCookie 使用单个 Cookie 签名、请求并解析贴纸列表
+// 签名依赖 Cookie 中的 msToken 等字段，因此每次切换 Cookie 都需重新签名
+func (s *DouyinSource) fetchWithCookie(ctx context.Context, baseURL string, params url.Values, cookie secrets.Secret, opts core.SearchOptions) (*douyinPage, error) {
+	// User-Agent 参与 X-Bogus 计算，签名与请求头必须使用本次轮换到的同一个值
+	profile := s.profile()
+	userAgent := profile.UserAgent
+	if s.endpoint.UserAgent != "" {
+		userAgent = s.endpoint.UserAgent
+	}
 
+	apiURL := baseURL + "?" + s.signer.WithUserAgent(userAgent).Sign(params, secrets.ParseCookie(cookie.Reveal()))
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Referer", "https://www.douyin.com/")
-	req.Header.Set("Cookie", s.cookie)
+	s.applyHeaders(req, profile, browser.KindAPI)
+	req.Header.Set("Referer", s.endpoint.Referer)
+	req.Header.Set("Cookie", cookie.Reveal())
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", core.ErrRequestFailed, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, &core.AuthError{Source: s.id, Reason: fmt.Sprintf("unexpected status code: %d", resp.StatusCode)}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &core.StatusError{Code: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read response body failed: %w", err)
 	}
-	fmt.Fprintf(os.Stderr, "🌐 [Response] %s\n", string(body))
+	logger.DebugContext(ctx, "douyin response received", "bytes", len(body))
 
 	var data douyinResponse
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := decodeJSON(ctx, bytes.NewReader(body), &data); err != nil {
+		return nil, err
+	}
+	if reason := douyinAuthFailure(resp, &data); reason != "" {
+		return nil, &core.AuthError{Source: s.id, Reason: reason}
+	}
+	if data.StatusCode != 0 {
+		return nil, fmt.Errorf("api returned error code: %d, msg: %s", data.StatusCode, data.StatusMsg)
 	}
 
-	var memes []core.Meme
+	result := &douyinPage{hasMore: bool(data.EmoticonData.HasMore)}
+	if cursor, err := data.EmoticonData.Cursor.Int64(); err == nil {
+		result.cursor = cursor
+	}
 	for _, item := range data.EmoticonData.StickerList {
-		if len(item.Origin.URLList) == 0 {
-			continue
+		if meme, ok := s.stickerToMeme(item); ok {
+			result.memes = append(result.memes, meme)
 		}
+	}
+	// 未返回 cursor 时按已返回的数量推算
+	if result.hasMore && result.cursor == 0 {
+		start, _ := strconv.ParseInt(params.Get("cursor"), 10, 64)
+		result.cursor = start + int64(len(data.EmoticonData.StickerList))
+	}
 
-		imgURL := core.NormalizeURL(item.Origin.URLList[0])
+	if opts.Limit > 0 && len(result.memes) > opts.Limit {
+		result.memes = result.memes[:opts.Limit]
+	}
 
-		// 过滤有效图片 URL
-		if !core.IsValidImageURL(imgURL) {
-			continue
-		}
+	return result, nil
+}
 
-		title := item.Author.Name
-		if title == "" {
-			title = "抖音表情"
+// stickerToMeme 将贴纸转换为表情包，优先使用动图，其次原图与静态图
+// 同一图片有多个 CDN 镜像，第一个有效地址作为主地址，其余镜像作为备用地址
+func (s *DouyinSource) stickerToMeme(item douyinSticker) (core.Meme, bool) {
+	var chosen *douyinImage
+	var candidates []string
+	for _, img := range []*douyinImage{&item.AnimateURL, &item.Origin, &item.StaticURL} {
+		if chosen == nil && len(core.CollectImageURLs(img.URLList...)) > 0 {
+			chosen = img
 		}
+		candidates = append(candidates, img.URLList...)
+	}
+	if chosen == nil {
+		return core.Meme{}, false
+	}
+	urls := core.CollectImageURLs(candidates...)
+	imgURL := urls[0]
 
-		memes = append(memes, core.Meme{
-			Title:    title,
-			URL:      imgURL,
-			Platform: s.id,
-			Format:   core.DetectImageFormat(imgURL),
-		})
+	thumbnail := imgURL
+	if thumbs := core.CollectImageURLs(append(item.Thumbnail.URLList, item.StaticURL.URLList...)...); len(thumbs) > 0 {
+		thumbnail = thumbs[0]
 	}
 
-	if opts.Limit > 0 && len(memes) > opts.Limit {
-		memes = memes[:opts.Limit]
+	title := strings.TrimSpace(item.Description)
+	if title == "" {
+		title = strings.TrimSpace(item.DisplayName)
+	}
+	if title == "" {
+		title = item.Author.Name
+	}
+	if title == "" {
+		title = "抖音表情"
 	}
 
-	return memes, nil
+	id := item.IDStr
+	if id == "" {
+		id = item.ID.String()
+	}
+	if id != "" {
+		id = s.id + "-" + id
+	}
+
+	width, height := chosen.Width, chosen.Height
+	if width == 0 || height == 0 {
+		width, height = item.Origin.Width, item.Origin.Height
+	}
+
+	return core.Meme{
+		ID:         id,
+		Title:      title,
+		URL:        imgURL,
+		Platform:   s.id,
+		Width:      width,
+		Height:     height,
+		Format:     core.DetectImageFormat(imgURL),
+		Thumbnail:  thumbnail,
+		Alternates: urls[1:],
+	}, true
+}
+
+// douyinNotLoggedIn 抖音接口表示未登录的 status_code
+const douyinNotLoggedIn = 8
+
+// douyinAuthFailure 判断响应是否表示 Cookie 失效，返回原因，正常时返回空串
+func douyinAuthFailure(resp *http.Response, data *douyinResponse) string {
+	if data.StatusCode == douyinNotLoggedIn || strings.Contains(data.StatusMsg, "登录") || strings.Contains(strings.ToLower(data.StatusMsg), "login") {
+		return fmt.Sprintf("not logged in (status_code=%d, msg=%s)", data.StatusCode, data.StatusMsg)
+	}
+	if data.StatusCode != 0 || (data.EmoticonData != nil && len(data.EmoticonData.StickerList) > 0) {
+		return ""
+	}
+
+	// 服务端在响应中清除了登录态
+	for _, c := range resp.Cookies() {
+		for _, name := range loginCookieNames {
+			if c.Name == name && (c.MaxAge < 0 || c.Value == "") {
+				return "session cleared by server (" + name + ")"
+			}
+		}
+	}
+
+	// 已登录时即使没有结果也会返回 emoticon_data，缺失说明被当作游客处理
+	if data.EmoticonData == nil {
+		return "response has no emoticon_data, cookie is likely logged out"
+	}
+	return ""
 }
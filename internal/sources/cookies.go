@@ -0,0 +1,173 @@
+package sources
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shadow/meme/internal/secrets"
+)
+
+// CookieRotation Cookie 池的轮换策略
+type CookieRotation string
+
+const (
+	// RotationFailover 始终使用第一个可用的 Cookie，失效后切换到下一个
+	RotationFailover CookieRotation = "failover"
+	// RotationRoundRobin 每次请求轮流使用可用的 Cookie，分摊限流
+	RotationRoundRobin CookieRotation = "round_robin"
+)
+
+// cookieRetryAfter 认证失败的 Cookie 在该时长后才会被再次尝试
+const cookieRetryAfter = 30 * time.Minute
+
+// loginCookieNames 表示已登录的 Cookie 字段，缺少全部字段时视为未登录
+// 抖音表情接口只对登录用户返回 emoticon_data，只有 ttwid、msToken 等游客字段的 Cookie 必然被当作游客，
+// 因此 acquire 不发请求直接跳过这类 Cookie (与认证失败一样 30 分钟后再检查)，doctor 中显示为不可用
+var loginCookieNames = []string{"sessionid", "sessionid_ss", "sid_tt"}
+
+// CookiePool 一组可轮换的 Cookie，认证失败的 Cookie 会被暂时禁用
+type CookiePool struct {
+	mu       sync.Mutex
+	entries  []*cookieEntry
+	rotation CookieRotation
+	next     int
+}
+
+type cookieEntry struct {
+	index    int
+	cookie   secrets.Secret
+	failedAt time.Time
+	reason   string
+}
+
+// CookieInfo Cookie 池中单个 Cookie 的状态 (Cookie 已掩码)
+type CookieInfo struct {
+	Index   int                  `json:"index"`
+	Cookie  secrets.Secret       `json:"cookie"`
+	Healthy bool                 `json:"healthy"`
+	Reason  string               `json:"reason,omitempty"` // 最近一次认证失败的原因
+	Expiry  secrets.CookieStatus `json:"expiry"`
+}
+
+// NewCookiePool 创建 Cookie 池，忽略空值；rotation 为空时使用 failover
+func NewCookiePool(cookies []secrets.Secret, rotation CookieRotation) *CookiePool {
+	if rotation == "" {
+		rotation = RotationFailover
+	}
+	return &CookiePool{rotation: rotation, entries: newCookieEntries(cookies)}
+}
+
+func newCookieEntries(cookies []secrets.Secret) []*cookieEntry {
+	var entries []*cookieEntry
+	for _, c := range cookies {
+		if c.IsZero() {
+			continue
+		}
+		entries = append(entries, &cookieEntry{index: len(entries) + 1, cookie: c})
+	}
+	return entries
+}
+
+// Replace 替换池中的全部 Cookie 并清空失败记录，轮换策略不变
+// 替换前已取出的 Cookie 仍可完成当前请求，其成功或失败不再影响新的 Cookie
+func (p *CookiePool) Replace(cookies []secrets.Secret) {
+	entries := newCookieEntries(cookies)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = entries
+	p.next = 0
+}
+
+// Len Cookie 数量
+func (p *CookiePool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// acquire 按轮换策略取一个可用的 Cookie，本地即可判定失效的 Cookie 会被直接禁用
+func (p *CookiePool) acquire(now time.Time) (*cookieEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.entries)
+	start := 0
+	if p.rotation == RotationRoundRobin {
+		start = p.next
+	}
+	for i := 0; i < n; i++ {
+		e := p.entries[(start+i)%n]
+		if !e.failedAt.IsZero() && now.Sub(e.failedAt) < cookieRetryAfter {
+			continue
+		}
+		if reason := localCookieProblem(e.cookie, now); reason != "" {
+			e.failedAt, e.reason = now, reason
+			continue
+		}
+		if p.rotation == RotationRoundRobin {
+			p.next = (start + i + 1) % n
+		}
+		return e, true
+	}
+	return nil, false
+}
+
+// markFailed 记录认证失败并暂时禁用该 Cookie
+func (p *CookiePool) markFailed(e *cookieEntry, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.failedAt, e.reason = time.Now(), reason
+}
+
+// markHealthy 请求成功后清除失败记录
+func (p *CookiePool) markHealthy(e *cookieEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.failedAt, e.reason = time.Time{}, ""
+}
+
+// Status 返回每个 Cookie 的当前状态
+func (p *CookiePool) Status() []CookieInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	infos := make([]CookieInfo, 0, len(p.entries))
+	for _, e := range p.entries {
+		healthy := e.failedAt.IsZero() || now.Sub(e.failedAt) >= cookieRetryAfter
+		reason := e.reason
+		if problem := localCookieProblem(e.cookie, now); problem != "" {
+			healthy, reason = false, problem
+		}
+		infos = append(infos, CookieInfo{
+			Index:   e.index,
+			Cookie:  e.cookie,
+			Healthy: healthy,
+			Reason:  reason,
+			Expiry:  secrets.CheckCookie(e.cookie.Reveal(), now),
+		})
+	}
+	return infos
+}
+
+// localCookieProblem 不发请求即可判定的失效原因，无问题时返回空串
+func localCookieProblem(cookie secrets.Secret, now time.Time) string {
+	pairs := secrets.ParseCookie(cookie.Reveal())
+	loggedIn := false
+	for _, name := range loginCookieNames {
+		if pairs[name] != "" {
+			loggedIn = true
+			break
+		}
+	}
+	if !loggedIn {
+		return "cookie has no login session (sessionid/sid_tt missing)"
+	}
+
+	if status := secrets.CheckCookie(cookie.Reveal(), now); status.State == secrets.CookieExpired {
+		return fmt.Sprintf("cookie expired at %s", status.Expires.Format(time.RFC3339))
+	}
+	return ""
+}
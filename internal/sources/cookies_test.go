@@ -0,0 +1,221 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/secrets"
+)
+
+func TestCookiePoolAcquire(t *testing.T) {
+	now := time.Now()
+	expired := "sessionid=c; sid_guard=c|" + strconv.FormatInt(now.Add(-48*time.Hour).Unix(), 10) + "|3600|x"
+
+	// step 对池的一次操作：fail 为真时将上一次取到的 Cookie 标记为失败，否则取一个 Cookie
+	type step struct {
+		fail  bool
+		after time.Duration // 相对 now 的取用时间
+		want  int           // 期望取到的 Cookie 序号，0 表示没有可用 Cookie
+	}
+	tests := []struct {
+		name     string
+		cookies  []string
+		rotation CookieRotation
+		steps    []step
+	}{
+		{
+			name:    "failover sticks to first",
+			cookies: []string{"sessionid=a", "sessionid=b"},
+			steps:   []step{{want: 1}, {want: 1}, {want: 1}},
+		},
+		{
+			name:    "failover switches after failure",
+			cookies: []string{"sessionid=a", "sessionid=b"},
+			steps:   []step{{want: 1}, {fail: true}, {want: 2}, {want: 2}},
+		},
+		{
+			name:    "failed cookie retried after window",
+			cookies: []string{"sessionid=a", "sessionid=b"},
+			steps: []step{
+				{want: 1}, {fail: true},
+				{after: cookieRetryAfter - time.Minute, want: 2},
+				{after: cookieRetryAfter + time.Minute, want: 1},
+			},
+		},
+		{
+			name:    "all failed",
+			cookies: []string{"sessionid=a", "sessionid=b"},
+			steps:   []step{{want: 1}, {fail: true}, {want: 2}, {fail: true}, {want: 0}},
+		},
+		{
+			name:     "round robin cycles",
+			cookies:  []string{"sessionid=a", "sid_tt=b", "sessionid_ss=c"},
+			rotation: RotationRoundRobin,
+			steps:    []step{{want: 1}, {want: 2}, {want: 3}, {want: 1}},
+		},
+		{
+			name:     "round robin skips failed",
+			cookies:  []string{"sessionid=a", "sessionid=b", "sessionid=c"},
+			rotation: RotationRoundRobin,
+			steps:    []step{{want: 1}, {want: 2}, {fail: true}, {want: 3}, {want: 1}, {want: 3}},
+		},
+		{
+			// 没有登录字段的 Cookie 必然被当作游客，不发请求直接跳过
+			name:    "logged out cookie skipped locally",
+			cookies: []string{"ttwid=guest; msToken=x", "sessionid=b"},
+			steps:   []step{{want: 2}, {after: cookieRetryAfter + time.Minute, want: 2}},
+		},
+		{
+			name:    "expired cookie skipped locally",
+			cookies: []string{expired, "sessionid=b"},
+			steps:   []step{{want: 2}},
+		},
+		{
+			name:    "empty cookies ignored",
+			cookies: []string{"", "sessionid=b"},
+			steps:   []step{{want: 1}},
+		},
+		{
+			name:  "empty pool",
+			steps: []step{{want: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cookies []secrets.Secret
+			for _, c := range tt.cookies {
+				cookies = append(cookies, secrets.New(c))
+			}
+			pool := NewCookiePool(cookies, tt.rotation)
+
+			var last *cookieEntry
+			for i, s := range tt.steps {
+				if s.fail {
+					pool.markFailed(last, "not logged in")
+					continue
+				}
+				e, ok := pool.acquire(now.Add(s.after))
+				got := 0
+				if ok {
+					got, last = e.index, e
+				}
+				if got != s.want {
+					t.Fatalf("step %d: acquire() = cookie #%d, want #%d", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCookiePoolStatus(t *testing.T) {
+	pool := NewCookiePool([]secrets.Secret{secrets.New("sessionid=abcdef123456"), secrets.New("ttwid=guest")}, "")
+	e, _ := pool.acquire(time.Now())
+	pool.markFailed(e, "not logged in")
+
+	infos := pool.Status()
+	if len(infos) != 2 {
+		t.Fatalf("Status() = %+v", infos)
+	}
+	if infos[0].Healthy || infos[0].Reason != "not logged in" {
+		t.Errorf("cookie #1 = %+v, want unhealthy after failure", infos[0])
+	}
+	if infos[1].Healthy || !strings.Contains(infos[1].Reason, "no login session") {
+		t.Errorf("cookie #2 = %+v, want rejected locally", infos[1])
+	}
+
+	pool.markHealthy(e)
+	if info := pool.Status()[0]; !info.Healthy || info.Reason != "" {
+		t.Errorf("cookie #1 after markHealthy = %+v", info)
+	}
+}
+
+func TestDouyinAuthFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		setCookie []string
+		want      string // 原因中应包含的片段，空串表示认证正常
+	}{
+		{"stickers", `{"status_code":0,"emoticon_data":{"sticker_list":[{"id":1}]}}`, nil, ""},
+		{"no results while logged in", `{"status_code":0,"emoticon_data":{"sticker_list":[]}}`, nil, ""},
+		{"not logged in status", `{"status_code":8,"status_msg":""}`, nil, "status_code=8"},
+		{"login message", `{"status_code":2483,"status_msg":"用户未登录"}`, nil, "not logged in"},
+		{"english login message", `{"status_code":1,"status_msg":"Please Login"}`, nil, "not logged in"},
+		{"other api error", `{"status_code":5,"status_msg":"rate limited"}`, nil, ""},
+		{"guest response", `{"status_code":0}`, nil, "no emoticon_data"},
+		{"session cleared", `{"status_code":0}`, []string{"sessionid=; Max-Age=0"}, "session cleared by server (sessionid)"},
+		{"unrelated cookie cleared", `{"status_code":0,"emoticon_data":{"sticker_list":[]}}`, []string{"ttwid=; Max-Age=0"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data douyinResponse
+			if err := json.Unmarshal([]byte(tt.body), &data); err != nil {
+				t.Fatal(err)
+			}
+			resp := &http.Response{Header: http.Header{"Set-Cookie": tt.setCookie}}
+
+			got := douyinAuthFailure(resp, &data)
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("douyinAuthFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookiePoolReplace(t *testing.T) {
+	pool := NewCookiePool([]secrets.Secret{secrets.New("sessionid=a"), secrets.New("sessionid=b")}, RotationRoundRobin)
+	first, _ := pool.acquire(time.Now())
+	pool.markFailed(first, "expired")
+
+	pool.Replace([]secrets.Secret{secrets.New("sessionid=c"), {}})
+	// 替换前取出的 Cookie 迟到的结果不影响新的 Cookie
+	pool.markFailed(first, "expired")
+
+	status := pool.Status()
+	if len(status) != 1 || status[0].Index != 1 || !status[0].Healthy {
+		t.Fatalf("Status() after Replace = %+v, want one healthy cookie", status)
+	}
+	if e, ok := pool.acquire(time.Now()); !ok || e.cookie.Reveal() != "sessionid=c" {
+		t.Errorf("acquire() after Replace = %v, %v, want the new cookie", e, ok)
+	}
+}
+
+// TestDouyinSetCookieDuringSearch 搜索进行中替换 Cookie，需配合 -race 运行
+func TestDouyinSetCookieDuringSearch(t *testing.T) {
+	src := NewDouyin("sessionid=first")
+	src.SetEndpoint(Endpoint{BaseURL: newMockUpstream(t).String()})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if _, err := src.Search(context.Background(), "猫", core.SearchOptions{Page: 1}); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		src.SetCookie("sessionid=" + strconv.Itoa(i))
+		src.Cookies().Status()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Search during SetCookie: %v", err)
+	}
+	if status := src.Cookies().Status(); len(status) != 1 || status[0].Cookie.Reveal() != "sessionid=19" {
+		t.Errorf("Cookies() = %+v, want only the last cookie", status)
+	}
+}
@@ -0,0 +1,53 @@
+package sources
+
+import "sync"
+
+// maxCursorEntries cursor 缓存的最大条数，超出后清空重建
+const maxCursorEntries = 1024
+
+// cursorCache 记录游标翻页接口每一页的起始 cursor，使按页码请求时无需从第一页重新翻
+type cursorCache struct {
+	mu    sync.Mutex
+	pages map[string]map[int]cursorState // 查询 -> 页码 -> 该页起始状态
+}
+
+// cursorState 某一页的起始 cursor，hasMore 为假表示上一页已是最后一页
+type cursorState struct {
+	cursor  int64
+	hasMore bool
+}
+
+func newCursorCache() *cursorCache {
+	return &cursorCache{pages: make(map[string]map[int]cursorState)}
+}
+
+// nearest 返回不超过 page 的最近已知页及其起始 cursor；都未知时从第一页 cursor 0 开始
+// ok 为假表示该页之前已没有更多结果
+func (c *cursorCache) nearest(key string, page int) (start int, cursor int64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pages := c.pages[key]
+	for p := page; p > 1; p-- {
+		if state, found := pages[p]; found {
+			return p, state.cursor, state.hasMore
+		}
+	}
+	return 1, 0, true
+}
+
+// record 记录第 page 页的起始 cursor (即上一页响应返回的 cursor)
+func (c *cursorCache) record(key string, page int, cursor int64, hasMore bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pages, ok := c.pages[key]
+	if !ok {
+		if len(c.pages) >= maxCursorEntries {
+			c.pages = make(map[string]map[int]cursorState)
+		}
+		pages = make(map[int]cursorState)
+		c.pages[key] = pages
+	}
+	pages[page] = cursorState{cursor: cursor, hasMore: hasMore}
+}
@@ -0,0 +1,78 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shadow/meme/internal/core"
+)
+
+func TestCursorCacheNearest(t *testing.T) {
+	c := newCursorCache()
+	c.record("q", 2, 20, true)
+	c.record("q", 3, 35, false)
+
+	tests := []struct {
+		page        int
+		wantStart   int
+		wantCursor  int64
+		wantHasMore bool
+	}{
+		{1, 1, 0, true},
+		{2, 2, 20, true},
+		{3, 3, 35, false},
+		{9, 3, 35, false},
+	}
+	for _, tt := range tests {
+		start, cursor, ok := c.nearest("q", tt.page)
+		if start != tt.wantStart || cursor != tt.wantCursor || ok != tt.wantHasMore {
+			t.Errorf("nearest(%d) = %d, %d, %v; want %d, %d, %v", tt.page, start, cursor, ok, tt.wantStart, tt.wantCursor, tt.wantHasMore)
+		}
+	}
+	if start, cursor, ok := c.nearest("other", 4); start != 1 || cursor != 0 || !ok {
+		t.Errorf("nearest(other, 4) = %d, %d, %v; want first page", start, cursor, ok)
+	}
+}
+
+func TestDouyinCursorWalkLimit(t *testing.T) {
+	// 每页返回 cursor+10 且总有下一页，统计请求次数
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cursor, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		fmt.Fprintf(w, `{"status_code":0,"emoticon_data":{"sticker_list":[],"has_more":1,"cursor":%d}}`, cursor+10)
+	}))
+	defer srv.Close()
+
+	src := NewDouyin("sessionid=test")
+	src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+
+	tests := []struct {
+		page         int
+		wantRequests int32
+		wantErr      bool
+	}{
+		{1, 1, false},
+		// 第 1 页记录了第 2 页的起始 cursor，从第 2 页起向后最多 maxCursorWalk 页
+		{2 + maxCursorWalk, maxCursorWalk + 1, false},
+		{100, 0, true},
+		// 此时已知到第 3+maxCursorWalk 页
+		{4 + 2*maxCursorWalk, 0, true},
+		{3 + 2*maxCursorWalk, maxCursorWalk + 1, false},
+	}
+	for _, tt := range tests {
+		requests.Store(0)
+		_, err := src.Search(context.Background(), "猫", core.SearchOptions{Page: tt.page})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("page %d: err = %v, wantErr %v", tt.page, err, tt.wantErr)
+		}
+		if got := requests.Load(); got != tt.wantRequests {
+			t.Errorf("page %d: %d requests, want %d", tt.page, got, tt.wantRequests)
+		}
+	}
+}
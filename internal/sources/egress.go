@@ -0,0 +1,237 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shadow/meme/internal/secrets"
+)
+
+// ProxyDirect 代理设为该值时不使用任何代理 (包括全局代理与 HTTP(S)_PROXY 环境变量)
+const ProxyDirect = "direct"
+
+// Egress 出站网络配置：代理、DNS、源地址绑定与 TLS
+// 全局配置作用于所有源，按源配置中非空的字段覆盖全局值
+type Egress struct {
+	// Proxy 代理地址：http://、https:// (CONNECT 隧道) 或 socks5://、socks5h://，认证信息写在 URL 中
+	// 为空时使用 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量，为 direct 时直连
+	Proxy string `json:"proxy,omitempty" yaml:"proxy"`
+	// DNSServer 自定义 DNS 服务器，如 223.5.5.5:53
+	DNSServer string `json:"dns_server,omitempty" yaml:"dns_server"`
+	// Resolve 固定解析，主机名 → IP (类似 curl --resolve)
+	Resolve map[string]string `json:"resolve,omitempty" yaml:"resolve"`
+	// BindAddr 出站连接绑定的本地 IP
+	BindAddr string `json:"bind_addr,omitempty" yaml:"bind_addr"`
+
+	// CAFile 追加信任的 CA 证书 (PEM)，用于企业中间人代理或自签名的镜像站
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file"`
+	// Pins 固定证书公钥指纹 (sha256/<base64>)，证书链中至少一个需匹配
+	Pins []string `json:"pins,omitempty" yaml:"pins"`
+	// Insecure 跳过证书校验，只允许按源开启
+	Insecure bool `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify"`
+}
+
+// MarshalJSON 代理地址中的密码只显示掩码
+func (e Egress) MarshalJSON() ([]byte, error) {
+	type plain Egress
+	masked := plain(e)
+	masked.Proxy = secrets.MaskURL(e.Proxy)
+	return json.Marshal(masked)
+}
+
+// IsZero 是否未配置任何字段
+func (e Egress) IsZero() bool {
+	return e.Proxy == "" && e.DNSServer == "" && len(e.Resolve) == 0 && e.BindAddr == "" &&
+		e.CAFile == "" && len(e.Pins) == 0 && !e.Insecure
+}
+
+// merge 用 override 中非空的字段覆盖 e，固定解析按主机名合并
+func (e Egress) merge(override Egress) Egress {
+	if override.Proxy != "" {
+		e.Proxy = override.Proxy
+	}
+	if override.DNSServer != "" {
+		e.DNSServer = override.DNSServer
+	}
+	if override.BindAddr != "" {
+		e.BindAddr = override.BindAddr
+	}
+	if len(override.Resolve) > 0 {
+		resolve := make(map[string]string, len(e.Resolve)+len(override.Resolve))
+		for host, ip := range e.Resolve {
+			resolve[host] = ip
+		}
+		for host, ip := range override.Resolve {
+			resolve[host] = ip
+		}
+		e.Resolve = resolve
+	}
+	if override.CAFile != "" {
+		e.CAFile = override.CAFile
+	}
+	if len(override.Pins) > 0 {
+		e.Pins = override.Pins
+	}
+	e.Insecure = e.Insecure || override.Insecure
+	return e
+}
+
+// validate 检查各字段格式
+func (e Egress) validate() error {
+	if _, err := e.proxyFunc(); err != nil {
+		return err
+	}
+	if e.DNSServer != "" {
+		if _, _, err := net.SplitHostPort(e.DNSServer); err != nil {
+			return fmt.Errorf("invalid dns server %q: %w", e.DNSServer, err)
+		}
+	}
+	for host, ip := range e.Resolve {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid resolve entry %s=%s: not an IP address", host, ip)
+		}
+	}
+	if e.BindAddr != "" && net.ParseIP(e.BindAddr) == nil {
+		return fmt.Errorf("invalid bind address %q", e.BindAddr)
+	}
+	if _, err := e.tlsConfig(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// proxyFunc 返回 http.Transport 使用的代理选择函数
+func (e Egress) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	switch e.Proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(e.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q", secrets.MaskURL(e.Proxy))
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: unsupported scheme %q", secrets.MaskURL(e.Proxy), u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", secrets.MaskURL(e.Proxy))
+	}
+	return http.ProxyURL(u), nil
+}
+
+// dialer 按配置创建拨号函数：固定解析 → 自定义 DNS → 绑定本地地址
+// 使用代理时拨号的目标是代理服务器，站点域名由代理解析，固定解析与 DNS 只作用于代理地址
+func (e Egress) dialer(base *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := *base
+	if e.BindAddr != "" {
+		d.LocalAddr = &net.TCPAddr{IP: net.ParseIP(e.BindAddr)}
+	}
+	if e.DNSServer != "" {
+		d.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dns := &net.Dialer{Timeout: base.Timeout}
+				if ip := net.ParseIP(e.BindAddr); ip != nil {
+					if strings.HasPrefix(network, "udp") {
+						dns.LocalAddr = &net.UDPAddr{IP: ip}
+					} else {
+						dns.LocalAddr = &net.TCPAddr{IP: ip}
+					}
+				}
+				return dns.DialContext(ctx, network, e.DNSServer)
+			},
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := e.Resolve[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// loadEgress 从环境变量读取全局与按源的出站配置
+// 全局：MEME_PROXY、MEME_DNS_SERVER、MEME_RESOLVE、MEME_BIND_ADDR；
+// 按源：<ID>_PROXY、<ID>_DNS_SERVER、<ID>_RESOLVE、<ID>_BIND_ADDR。
+// TLS：MEME_CA_FILE、MEME_TLS_PINS 及对应的 <ID>_ 变量，跳过证书校验只能按源设置 (<ID>_TLS_INSECURE)。
+// 代理地址可能带密码，与 Cookie 一样也可通过 *_FILE 或加密存储设置
+func loadEgress() (Egress, map[string]Egress, error) {
+	global, err := loadEgressEnv("MEME_", false)
+	if err != nil {
+		return Egress{}, nil, fmt.Errorf("egress: %w", err)
+	}
+
+	perSource := make(map[string]Egress)
+	for _, id := range endpointSourceIDs {
+		e, err := loadEgressEnv(strings.ToUpper(id)+"_", true)
+		if err != nil {
+			return Egress{}, nil, fmt.Errorf("%s egress: %w", id, err)
+		}
+		if !e.IsZero() {
+			perSource[id] = e
+		}
+		if e.Insecure {
+			logger.Warn("tls certificate verification disabled", "source", id)
+		}
+	}
+	return global, perSource, nil
+}
+
+func loadEgressEnv(prefix string, allowInsecure bool) (Egress, error) {
+	proxy, _, err := secrets.Load(prefix + "PROXY")
+	if err != nil {
+		return Egress{}, err
+	}
+
+	e := Egress{
+		Proxy:     strings.TrimSpace(proxy.Reveal()),
+		DNSServer: strings.TrimSpace(os.Getenv(prefix + "DNS_SERVER")),
+		BindAddr:  strings.TrimSpace(os.Getenv(prefix + "BIND_ADDR")),
+		CAFile:    strings.TrimSpace(os.Getenv(prefix + "CA_FILE")),
+		Pins:      splitComma(os.Getenv(prefix + "TLS_PINS")),
+	}
+	if allowInsecure {
+		e.Insecure, _ = strconv.ParseBool(os.Getenv(prefix + "TLS_INSECURE"))
+	}
+	// MEME_RESOLVE=www.doutupk.com=1.2.3.4,pdan.com.cn=5.6.7.8
+	for _, pair := range strings.Split(os.Getenv(prefix+"RESOLVE"), ",") {
+		host, ip, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if e.Resolve == nil {
+			e.Resolve = make(map[string]string)
+		}
+		e.Resolve[strings.ToLower(strings.TrimSpace(host))] = strings.TrimSpace(ip)
+	}
+
+	if err := e.validate(); err != nil {
+		return Egress{}, err
+	}
+	return e, nil
+}
+
+// splitComma 按逗号拆分并去除空白与空项
+func splitComma(raw string) []string {
+	var parts []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Endpoint 源访问的站点地址，可指向镜像站、出口网关或本地模拟服务
+// 空字段表示使用源的内置默认值
+type Endpoint struct {
+	BaseURL   string `json:"base_url,omitempty" yaml:"base_url"`     // 站点根地址，如 https://pdan.com.cn
+	Referer   string `json:"referer,omitempty" yaml:"referer"`       // 请求携带的 Referer，图片代理也使用该值
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent"` // 覆盖浏览器配置中的 User-Agent
+}
+
+// merge 用 override 中非空的字段覆盖 e
+func (e Endpoint) merge(override Endpoint) Endpoint {
+	if override.BaseURL != "" {
+		e.BaseURL = strings.TrimRight(override.BaseURL, "/")
+	}
+	if override.Referer != "" {
+		e.Referer = override.Referer
+	}
+	if override.UserAgent != "" {
+		e.UserAgent = override.UserAgent
+	}
+	return e
+}
+
+// url 拼接站点根地址与路径 (路径以 / 开头，可带查询串)
+func (e Endpoint) url(path string) string {
+	return e.BaseURL + path
+}
+
+// origin 返回 Referer 的源 (scheme://host)，用于 Origin 请求头
+func (e Endpoint) origin() string {
+	u, err := url.Parse(e.Referer)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// validate 检查地址格式，BaseURL 与 Referer 必须是 http(s) 绝对地址
+func (e Endpoint) validate() error {
+	if err := checkHTTPURL(e.BaseURL); err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	if err := checkHTTPURL(e.Referer); err != nil {
+		return fmt.Errorf("invalid referer: %w", err)
+	}
+	return nil
+}
+
+func checkHTTPURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
+	}
+	return nil
+}
+
+// endpointSourceIDs 支持按源配置站点地址与出站网络的内置源
+var endpointSourceIDs = []string{"sougou", "doutub", "douyin", "qudoutu", "doutula", "pdan"}
+
+// loadEndpoints 从环境变量读取各源的地址覆盖
+// <ID>_BASE_URL、<ID>_REFERER、<ID>_USER_AGENT，如 PDAN_BASE_URL=https://pdan.example.com
+func loadEndpoints() (map[string]Endpoint, error) {
+	endpoints := make(map[string]Endpoint)
+	for _, id := range endpointSourceIDs {
+		prefix := strings.ToUpper(id) + "_"
+		e := Endpoint{
+			BaseURL:   strings.TrimSpace(os.Getenv(prefix + "BASE_URL")),
+			Referer:   strings.TrimSpace(os.Getenv(prefix + "REFERER")),
+			UserAgent: strings.TrimSpace(os.Getenv(prefix + "USER_AGENT")),
+		}
+		if e == (Endpoint{}) {
+			continue
+		}
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("%s endpoint: %w", id, err)
+		}
+		endpoints[id] = e
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,139 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/shadow/meme/internal/core"
+)
+
+// pageLayout 列表页的结构约定，用于区分 "页面没有结果" 与 "页面结构变化"
+type pageLayout struct {
+	container string   // 结果区域，用于截取错误信息中的 HTML 片段
+	items     string   // 结果条目
+	image     string   // 条目中的图片，条目存在但取不到图片地址时报告该选择器
+	empty     []string // 表示没有结果的元素 (如 WordPress 的 body.search-no-results)
+}
+
+// emptyTextMarkers 结果区域中表示没有结果的提示文字，各站点通用
+// 只在 container 内查找，页头页脚中的 "暂无" 等文字不会让结构变化被当作没有结果
+var emptyTextMarkers = []string{"没有找到", "未找到", "暂无", "没有相关", "nothing found", "no results"}
+
+const (
+	snippetLength = 300 // 错误信息中 HTML 片段的最大长度 (字符)
+	maxDumpFiles  = 50  // 每个源在保存目录中最多保留的页面数，站点改版后每次请求都会失败，避免写满磁盘
+)
+
+// errDumpLimit 保存目录中该源的页面已达上限
+var errDumpLimit = errors.New("page dump limit reached")
+
+// SetDumpDir 设置解析失败时保存页面的目录，为空时不保存
+func (b *BaseSource) SetDumpDir(dir string) {
+	b.dumpDir = dir
+}
+
+// checkLayout 列表页没有解析出表情包时，判断是页面没有结果还是结构已变化
+// items 为匹配到的条目数，parsed 为解析出的表情包数；结构变化时返回 *core.ParseError，并按配置保存页面
+func (b *BaseSource) checkLayout(ctx context.Context, doc *goquery.Document, pageURL string, layout pageLayout, items, parsed int) error {
+	if parsed > 0 {
+		return nil
+	}
+	if items == 0 && isEmptyPage(doc, layout) {
+		logger.DebugContext(ctx, "page has no results", "source", b.id, "url", pageURL)
+		return nil
+	}
+
+	selector := layout.items
+	if items > 0 {
+		selector = layout.items + " " + layout.image
+	}
+	err := &core.ParseError{
+		Source:   b.id,
+		URL:      pageURL,
+		Selector: selector,
+		Snippet:  pageSnippet(doc, layout.container),
+	}
+	if b.dumpDir != "" {
+		path, dumpErr := dumpPage(b.dumpDir, b.id, pageURL, doc)
+		switch {
+		case errors.Is(dumpErr, errDumpLimit):
+			logger.DebugContext(ctx, "page not saved", "source", b.id, "error", dumpErr, "limit", maxDumpFiles)
+		case dumpErr != nil:
+			logger.WarnContext(ctx, "save page failed", "source", b.id, "error", dumpErr)
+		}
+		err.DumpPath = path
+	}
+	logger.WarnContext(ctx, "page layout changed", "source", b.id, "url", pageURL,
+		"selector", selector, "snippet", err.Snippet, "dump", err.DumpPath)
+	return err
+}
+
+// isEmptyPage 页面中是否有表示没有结果的元素，或结果区域中是否有提示文字
+func isEmptyPage(doc *goquery.Document, layout pageLayout) bool {
+	for _, selector := range layout.empty {
+		if doc.Find(selector).Length() > 0 {
+			return true
+		}
+	}
+	text := strings.ToLower(doc.Find(layout.container).Text())
+	for _, marker := range emptyTextMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageSnippet 截取结果区域 (找不到时为 body) 的 HTML，合并空白并截断
+func pageSnippet(doc *goquery.Document, container string) string {
+	sel := doc.Find(container).First()
+	if sel.Length() == 0 {
+		sel = doc.Find("body")
+	}
+	html, err := goquery.OuterHtml(sel)
+	if err != nil {
+		return ""
+	}
+	html = strings.Join(strings.Fields(html), " ")
+	if runes := []rune(html); len(runes) > snippetLength {
+		html = string(runes[:snippetLength]) + "…"
+	}
+	return html
+}
+
+// dumpPage 将页面保存到 dir/<源>-<时间>-<URL 摘要>.html (权限 0600)，首行注释记录页面地址，便于整理为测试数据
+// 保存的是转换为 UTF-8 并经过解析后的页面；该源已有 maxDumpFiles 个页面时返回 errDumpLimit
+func dumpPage(dir, source, pageURL string, doc *goquery.Document) (string, error) {
+	existing, err := filepath.Glob(filepath.Join(dir, source+"-*.html"))
+	if err != nil {
+		return "", err
+	}
+	if len(existing) >= maxDumpFiles {
+		return "", errDumpLimit
+	}
+
+	html, err := doc.Html()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum([]byte(pageURL))
+	name := fmt.Sprintf("%s-%s-%s.html", source, time.Now().Format("20060102-150405"), hex.EncodeToString(sum[:4]))
+	path := filepath.Join(dir, name)
+	content := fmt.Sprintf("<!-- %s -->\n%s", strings.ReplaceAll(pageURL, "--", "%2D%2D"), html)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/shadow/meme/internal/core"
+)
+
+func TestLayoutChangedDump(t *testing.T) {
+	// 站点改版：条目改用新的 class，并带有大量无关内容；页脚的 "暂无" 不在结果区域内
+	page := `<!DOCTYPE html><html><head><meta charset="utf-8"></head><body><div class="random_picture">` +
+		strings.Repeat(`<a class="meme-card" href="/photo/1"><img data-src="https://img.doutupk.com/new.gif"></a>`, 20) +
+		`</div><footer>暂无广告</footer></body></html>`
+	srv := newUpstreamServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}), false)
+
+	dir := t.TempDir()
+	src := NewDoutula()
+	src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+	src.SetDumpDir(dir)
+
+	_, err := src.Search(context.Background(), "猫", core.SearchOptions{})
+	var parseErr *core.ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, core.ErrLayoutChanged) {
+		t.Fatalf("err = %v, want *core.ParseError", err)
+	}
+	if parseErr.Selector != doutulaLayout.items || !strings.HasPrefix(parseErr.Snippet, `<div class="random_picture">`) {
+		t.Errorf("selector = %q, snippet = %q", parseErr.Selector, parseErr.Snippet)
+	}
+	if n := utf8.RuneCountInString(parseErr.Snippet); n > snippetLength+1 {
+		t.Errorf("snippet has %d characters, want at most %d", n, snippetLength+1)
+	}
+	if got := core.ErrorKind(err); got != "layout" {
+		t.Errorf("ErrorKind = %q, want layout", got)
+	}
+
+	data, err := os.ReadFile(parseErr.DumpPath)
+	if err != nil {
+		t.Fatalf("read dumped page: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<!-- "+srv.URL+"/search?keyword=") || !strings.Contains(string(data), `class="meme-card"`) {
+		t.Errorf("dumped page = %.200s", data)
+	}
+	if info, err := os.Stat(parseErr.DumpPath); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("dumped page mode = %o, want 600", perm)
+	}
+
+	// 达到上限后不再保存，但仍报告结构变化
+	for i := 0; i < maxDumpFiles; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("doutula-old-%d.html", i)), nil, 0o600)
+	}
+	_, err = src.Search(context.Background(), "猫", core.SearchOptions{})
+	if !errors.As(err, &parseErr) || parseErr.DumpPath != "" {
+		t.Fatalf("err = %v, want *core.ParseError without dump", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "doutula-*.html")); len(files) != maxDumpFiles+1 {
+		t.Errorf("%d pages in dump dir, want %d", len(files), maxDumpFiles+1)
+	}
+}
+
+func TestIsEmptyPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout pageLayout
+		page   string
+		want   bool
+	}{
+		{"doutula hint", doutulaLayout, `<div class="random_picture"><div class="page-content text-center"><p>换个关键词试试</p></div></div>`, true},
+		{"qudoutu hint", qudoutuLayout, `<div class="item-grid"><ul></ul></div><div class="empty">换个关键词试试</div>`, true},
+		{"pdan not found", pdanLayout, `<main class="site-main"><section class="no-results not-found"><h1>Nothing Found</h1></section></main>`, true},
+		{"marker text in container", qudoutuLayout, `<div class="item-grid"><p>没有找到相关表情</p></div>`, true},
+		{"marker text outside container", qudoutuLayout, `<div class="item-grid"><ul class="v2"></ul></div><footer>暂无广告</footer>`, false},
+		{"container missing", doutulaLayout, `<div class="grid"></div><p>没有找到</p>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isEmptyPage(doc, tt.layout); got != tt.want {
+				t.Errorf("isEmptyPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutPastLastPage(t *testing.T) {
+	upstream := newMockUpstream(t)
+
+	// 模拟站点在超出最后一页时显示 "没有结果" 的提示，不应报告结构变化
+	for _, src := range []interface {
+		core.Browser
+		SetEndpoint(Endpoint)
+	}{NewDoutula(), NewPdan()} {
+		src.SetEndpoint(Endpoint{BaseURL: upstream.String()})
+		memes, err := src.Latest(context.Background(), core.SearchOptions{Page: 5})
+		if err != nil || len(memes) != 0 {
+			t.Errorf("%T page 5: %d memes, err = %v; want empty without error", src, len(memes), err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shadow/meme/internal/browser"
+)
+
+// BrowserConfig 源使用的浏览器请求头配置 (见 internal/browser)
+type BrowserConfig struct {
+	Profiles []string         `json:"profiles,omitempty" yaml:"profiles"` // 参与轮换的配置名称，all 表示全部内置配置
+	Rotation browser.Rotation `json:"rotation,omitempty" yaml:"rotation"` // fixed / sticky / round_robin / random
+}
+
+// IsZero 是否未配置
+func (c BrowserConfig) IsZero() bool {
+	return len(c.Profiles) == 0 && c.Rotation == ""
+}
+
+// merge 用 override 中非空的字段覆盖 c
+func (c BrowserConfig) merge(override BrowserConfig) BrowserConfig {
+	if len(override.Profiles) > 0 {
+		c.Profiles = override.Profiles
+	}
+	if override.Rotation != "" {
+		c.Rotation = override.Rotation
+	}
+	return c
+}
+
+// rotator 按配置创建轮换器
+func (c BrowserConfig) rotator() (*browser.Rotator, error) {
+	profiles, err := browser.Resolve(c.Profiles)
+	if err != nil {
+		return nil, err
+	}
+	rotation, err := browser.ParseRotation(string(c.Rotation))
+	if err != nil {
+		return nil, err
+	}
+	return browser.NewRotator(profiles, rotation), nil
+}
+
+// loadBrowserConfigs 从环境变量读取全局与按源的浏览器配置
+// 全局：MEME_BROWSER_PROFILES、MEME_BROWSER_ROTATION；按源：<ID>_BROWSER_PROFILES、<ID>_BROWSER_ROTATION
+func loadBrowserConfigs() (BrowserConfig, map[string]BrowserConfig, error) {
+	global, err := loadBrowserEnv("MEME_")
+	if err != nil {
+		return BrowserConfig{}, nil, fmt.Errorf("browser: %w", err)
+	}
+
+	perSource := make(map[string]BrowserConfig)
+	for _, id := range endpointSourceIDs {
+		c, err := loadBrowserEnv(strings.ToUpper(id) + "_")
+		if err != nil {
+			return BrowserConfig{}, nil, fmt.Errorf("%s browser: %w", id, err)
+		}
+		if !c.IsZero() {
+			perSource[id] = c
+		}
+	}
+	return global, perSource, nil
+}
+
+func loadBrowserEnv(prefix string) (BrowserConfig, error) {
+	c := BrowserConfig{
+		Profiles: splitComma(os.Getenv(prefix + "BROWSER_PROFILES")),
+		Rotation: browser.Rotation(strings.TrimSpace(os.Getenv(prefix + "BROWSER_ROTATION"))),
+	}
+	if _, err := c.rotator(); err != nil {
+		return BrowserConfig{}, err
+	}
+	return c, nil
+}
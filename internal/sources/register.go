@@ -1,32 +1,192 @@
 package sources
 
-import "github.com/shadow/meme/internal/core"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/secrets"
+)
 
 // RegisterAllSources 注册所有内置源到注册中心
 func RegisterAllSources(registry *core.Registry, config *Config) {
 	// 注册无需认证的源
-	registry.Register(NewDoutula())
-	registry.Register(NewPdan())
-	registry.Register(NewSougou())
+	registry.Register(Configure(NewDoutula(), config))
+	registry.Register(Configure(NewPdan(), config))
+	registry.Register(Configure(NewSougou(), config))
 
 	if config != nil {
 		// 注册需要代理的源 (qudoutu, doutub)
 		if config.ImageProxyURL != "" {
-			registry.Register(NewQudoutu())
-			registry.Register(NewDoutub())
+			registry.Register(Configure(NewQudoutu(), config))
+			registry.Register(Configure(NewDoutub(), config))
 		}
 
 		// 注册需要认证的源 (如果配置了 Cookie)
-		if config.DouyinCookie != "" {
-			registry.Register(NewDouyin(config.DouyinCookie))
+		if len(config.DouyinCookies) > 0 {
+			registry.Register(Configure(NewDouyinWithPool(NewCookiePool(config.DouyinCookies, config.DouyinCookieRotation)), config))
 		}
 	}
 }
 
-// Config 源配置
+// ConfigurableSource 支持按源覆盖站点地址、出站网络、浏览器配置与会话的源
+type ConfigurableSource interface {
+	core.Source
+	SetEndpoint(Endpoint)
+	SetEgress(Egress) error
+	SetBrowser(BrowserConfig) error
+	SetSession(SessionConfig) error
+	SetDumpDir(string)
+}
+
+// Configure 按配置覆盖源的站点地址、出站网络、浏览器配置与会话 (全局配置与按源配置合并)
+// 配置已在 LoadConfig 中校验，这里出错时只记录警告并保留默认客户端
+func Configure(source ConfigurableSource, config *Config) core.Source {
+	if config == nil {
+		return source
+	}
+	if e, ok := config.Endpoints[source.ID()]; ok {
+		source.SetEndpoint(e)
+	}
+	if egress := config.egressFor(source.ID()); !egress.IsZero() {
+		if err := source.SetEgress(egress); err != nil {
+			logger.Warn("invalid egress config, using direct connection", "source", source.ID(), "error", err)
+		}
+	}
+	if b := config.Browser.merge(config.SourceBrowser[source.ID()]); !b.IsZero() {
+		if err := source.SetBrowser(b); err != nil {
+			logger.Warn("invalid browser config, using default profile", "source", source.ID(), "error", err)
+		}
+	}
+	session := config.Session.merge(config.SourceSession[source.ID()])
+	if err := source.SetSession(session); err != nil {
+		// 保存的 Cookie 文件损坏时不影响启动，改为只在内存中保存
+		logger.Warn("load saved session failed, starting with empty cookie jar", "source", source.ID(), "error", err)
+		session.Dir = ""
+		source.SetSession(session)
+	}
+	source.SetDumpDir(config.DumpDir)
+	return source
+}
+
+// MarshalJSON 图片代理地址中的凭据只显示掩码，Cookie 与出站代理由各自的类型掩码
+func (c Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	masked := plain(c)
+	masked.ImageProxyURL = secrets.MaskURL(c.ImageProxyURL)
+	return json.Marshal(masked)
+}
+
+// egressFor 返回源的出站配置：按源配置中非空的字段覆盖全局配置
+func (c *Config) egressFor(id string) Egress {
+	return c.Egress.merge(c.SourceEgress[id])
+}
+
+// Config 源配置，Cookie、图片代理与出站代理地址在格式化输出与 JSON 中只显示掩码
 type Config struct {
-	DouyinCookie  string `json:"douyin_cookie" yaml:"douyin_cookie"`
-	ImageProxyURL string `json:"image_proxy_url" yaml:"image_proxy_url"`
+	DouyinCookies        []secrets.Secret `json:"douyin_cookies" yaml:"douyin_cookies"`
+	DouyinCookieRotation CookieRotation   `json:"douyin_cookie_rotation,omitempty" yaml:"douyin_cookie_rotation"`
+	ImageProxyURL        string           `json:"image_proxy_url" yaml:"image_proxy_url"`
+
+	// Endpoints 按源 ID 覆盖站点地址、Referer 与 User-Agent (镜像站、出口网关、本地模拟服务)
+	Endpoints map[string]Endpoint `json:"endpoints,omitempty" yaml:"endpoints"`
+
+	// Egress 全局出站网络配置 (代理、DNS、源地址绑定)，SourceEgress 按源 ID 覆盖
+	Egress       Egress            `json:"egress" yaml:"egress"`
+	SourceEgress map[string]Egress `json:"source_egress,omitempty" yaml:"source_egress"`
+
+	// Browser 全局浏览器请求头配置与轮换策略，SourceBrowser 按源 ID 覆盖
+	Browser       BrowserConfig            `json:"browser" yaml:"browser"`
+	SourceBrowser map[string]BrowserConfig `json:"source_browser,omitempty" yaml:"source_browser"`
+
+	// Session 全局会话配置 (Cookie 保存目录、首页预热)，SourceSession 按源 ID 覆盖
+	Session       SessionConfig            `json:"session" yaml:"session"`
+	SourceSession map[string]SessionConfig `json:"source_session,omitempty" yaml:"source_session"`
+
+	// DumpDir 页面结构变化 (解析不到结果) 时保存原始页面的目录，为空时不保存
+	DumpDir string `json:"dump_dir,omitempty" yaml:"dump_dir"`
+
+	// DouyinCookieOrigin Cookie 的来源 (env/file/store)，用于诊断输出
+	DouyinCookieOrigin secrets.Origin `json:"douyin_cookie_origin,omitempty" yaml:"-"`
+}
+
+// LoadConfig 从环境变量、秘密文件或加密存储加载配置
+// DOUYIN_COOKIE 可直接设置，也可通过 DOUYIN_COOKIE_FILE 指向文件，或保存在加密存储中；
+// 多个 Cookie 每行一个，按 DOUYIN_COOKIE_ROTATION (failover/round_robin) 轮换；
+// 各源的站点地址通过 <ID>_BASE_URL、<ID>_REFERER、<ID>_USER_AGENT 覆盖；
+// 出站代理、DNS 与源地址绑定见 loadEgress，浏览器请求头配置见 loadBrowserConfigs，会话见 loadSessions；
+// 页面结构变化时保存页面的目录通过 MEME_DUMP_DIR 设置
+func LoadConfig() (*Config, error) {
+	raw, origin, err := secrets.Load("DOUYIN_COOKIE")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := loadEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	egress, sourceEgress, err := loadEgress()
+	if err != nil {
+		return nil, err
+	}
+	browserConfig, sourceBrowser, err := loadBrowserConfigs()
+	if err != nil {
+		return nil, err
+	}
+	sessionConfig, sourceSession, err := loadSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{
+		DouyinCookies:        splitCookies(raw),
+		DouyinCookieRotation: CookieRotation(os.Getenv("DOUYIN_COOKIE_ROTATION")),
+		DouyinCookieOrigin:   origin,
+		ImageProxyURL:        os.Getenv("IMAGE_PROXY_URL"),
+		Endpoints:            endpoints,
+		Egress:               egress,
+		SourceEgress:         sourceEgress,
+		Browser:              browserConfig,
+		SourceBrowser:        sourceBrowser,
+		Session:              sessionConfig,
+		SourceSession:        sourceSession,
+		DumpDir:              strings.TrimSpace(os.Getenv("MEME_DUMP_DIR")),
+	}
+
+	switch config.DouyinCookieRotation {
+	case "", RotationFailover, RotationRoundRobin:
+	default:
+		return nil, fmt.Errorf("invalid DOUYIN_COOKIE_ROTATION: %s", config.DouyinCookieRotation)
+	}
+
+	for i, cookie := range config.DouyinCookies {
+		status := secrets.CheckCookie(cookie.Reveal(), time.Now())
+		switch status.State {
+		case secrets.CookieExpired:
+			logger.Warn("douyin cookie has expired, please update DOUYIN_COOKIE", "slot", i+1, "expires", status.Expires)
+		case secrets.CookieExpiring:
+			logger.Warn("douyin cookie expires soon", "slot", i+1, "expires", status.Expires)
+		}
+	}
+
+	return config, nil
+}
+
+// splitCookies 按行拆分多个 Cookie，忽略空行与 # 注释
+func splitCookies(raw secrets.Secret) []secrets.Secret {
+	var cookies []secrets.Secret
+	for _, line := range strings.Split(raw.Reveal(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cookies = append(cookies, secrets.New(line))
+	}
+	return cookies
 }
 
 // GetAllSourceInfo 获取所有源的信息 (用于 list_sources Tool)
@@ -40,6 +200,7 @@ func GetAllSourceInfo(registry *core.Registry) []SourceInfo {
 			Name:         s.Name(),
 			Description:  s.Description(),
 			RequiresAuth: s.RequiresAuth(),
+			Capabilities: s.Capabilities(),
 		})
 	}
 
@@ -48,8 +209,9 @@ func GetAllSourceInfo(registry *core.Registry) []SourceInfo {
 
 // SourceInfo 源信息结构
 type SourceInfo struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	RequiresAuth bool   `json:"requires_auth"`
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	RequiresAuth bool              `json:"requires_auth"`
+	Capabilities core.Capabilities `json:"capabilities"`
 }
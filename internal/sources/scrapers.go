@@ -1,9 +1,9 @@
 package sources
 
 import (
-	"compress/gzip"
+	"bytes"
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -14,42 +14,143 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/shadow/meme/internal/browser"
 	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
 )
 
+var logger = utils.Logger("sources")
+
 // BaseSource 提供通用字段和方法，减少重复代码
 type BaseSource struct {
-	id          string
-	name        string
-	description string
-	requireAuth bool
-	client      *http.Client
+	id           string
+	name         string
+	description  string
+	requireAuth  bool
+	capabilities core.Capabilities
+	client       *http.Client
+	endpoint     Endpoint
+	egress       Egress
+	profiles     *browser.Rotator // 为空时使用默认浏览器配置
+	session      *session         // 为空时不保存 Cookie、不预热
+	dumpDir      string           // 页面结构变化时保存页面的目录
+}
+
+func (b *BaseSource) ID() string                      { return b.id }
+func (b *BaseSource) Name() string                    { return b.name }
+func (b *BaseSource) Description() string             { return b.description }
+func (b *BaseSource) RequiresAuth() bool              { return b.requireAuth }
+func (b *BaseSource) Capabilities() core.Capabilities { return b.capabilities }
+
+// SetTransport 替换源发送请求使用的 Transport (如测试中的录制/回放)，保留原有超时与 Cookie Jar
+func (b *BaseSource) SetTransport(rt http.RoundTripper) {
+	b.client = &http.Client{Timeout: b.client.Timeout, Transport: rt, Jar: b.client.Jar}
+}
+
+// Transport 返回源当前使用的 Transport
+func (b *BaseSource) Transport() http.RoundTripper {
+	return b.client.Transport
+}
+
+// SetEndpoint 覆盖源的站点地址、Referer 与 User-Agent，空字段保留默认值
+func (b *BaseSource) SetEndpoint(e Endpoint) {
+	b.endpoint = b.endpoint.merge(e)
 }
 
-func (b *BaseSource) ID() string          { return b.id }
-func (b *BaseSource) Name() string        { return b.name }
-func (b *BaseSource) Description() string { return b.description }
-func (b *BaseSource) RequiresAuth() bool  { return b.requireAuth }
+// Endpoint 返回源当前使用的站点地址
+func (b *BaseSource) Endpoint() Endpoint {
+	return b.endpoint
+}
+
+// SetBrowser 设置源使用的浏览器配置与轮换策略
+func (b *BaseSource) SetBrowser(c BrowserConfig) error {
+	rotator, err := c.rotator()
+	if err != nil {
+		return err
+	}
+	b.profiles = rotator
+	return nil
+}
+
+// BrowserProfiles 返回源参与轮换的浏览器配置名称
+func (b *BaseSource) BrowserProfiles() []string {
+	if b.profiles == nil {
+		return []string{browser.DefaultName}
+	}
+	return b.profiles.Profiles()
+}
+
+// profile 返回本次请求使用的浏览器配置
+func (b *BaseSource) profile() *browser.Profile {
+	if b.profiles == nil {
+		return browser.Default()
+	}
+	return b.profiles.Next()
+}
+
+// applyHeaders 按浏览器配置设置请求头，站点配置了 User-Agent 时覆盖配置中的值
+func (b *BaseSource) applyHeaders(req *http.Request, profile *browser.Profile, kind browser.Kind) {
+	profile.Apply(req.Header, kind)
+	if b.endpoint.UserAgent != "" {
+		req.Header.Set("User-Agent", b.endpoint.UserAgent)
+	}
+}
+
+// fetchDocument 使用本次轮换到的浏览器配置抓取站点内的页面，Referer 为站点地址
+func (b *BaseSource) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	return fetchHTML(ctx, b.do, b.profile(), pageURL, map[string]string{
+		"Referer":        b.endpoint.Referer,
+		"Sec-Fetch-Site": "same-origin",
+		"User-Agent":     b.endpoint.UserAgent,
+	})
+}
+
+// SetEgress 按出站配置 (代理、DNS、源地址绑定) 重建 HTTP 客户端，保留原有超时与 Cookie Jar
+func (b *BaseSource) SetEgress(e Egress) error {
+	client, err := newEgressClient(e)
+	if err != nil {
+		return err
+	}
+	client.Timeout = b.client.Timeout
+	client.Jar = b.client.Jar
+	b.client = client
+	b.egress = e
+	return nil
+}
 
 // newHTTPClient 创建带默认配置的 HTTP 客户端
-// 增强 TLS 兼容性，解决某些网站的握手失败问题
 func newHTTPClient() *http.Client {
+	// 默认配置不会出错
+	client, _ := newEgressClient(Egress{})
+	return client
+}
+
+// newEgressClient 按出站配置创建 HTTP 客户端
+func newEgressClient(e Egress) (*http.Client, error) {
+	proxy, err := e.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := e.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	return &http.Client{
 		Timeout: 15 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				// 允许较旧的 TLS 版本以兼容更多网站
-				MinVersion: tls.VersionTLS10,
-				// 使用更宽松的加密套件
-				CipherSuites: nil, // nil 表示使用 Go 默认的所有套件
-				// 跳过证书验证 (某些网站证书配置有问题)
-				InsecureSkipVerify: true,
-			},
-			// 连接配置
-			DialContext: (&net.Dialer{
+		// 每个请求记录链路 Span (DNS、建连、TLS、等待首字节) 与 debug 日志
+		// 响应按 Content-Encoding 解压 (gzip、deflate、br、zstd)，上层拿到的都是原始内容
+		Transport: tracing.NewTransport(utils.NewLoggingTransport(utils.NewDecodingTransport(&http.Transport{
+			// 代理：HTTP CONNECT 或 SOCKS5，未配置时读取 HTTP(S)_PROXY 环境变量
+			Proxy: proxy,
+			// 默认校验证书，个别证书配置有问题的站点按源配置 CA、指纹或跳过校验
+			TLSClientConfig: tlsConfig,
+			// 连接配置：固定解析、自定义 DNS 与源地址绑定
+			DialContext: e.dialer(&net.Dialer{
 				Timeout:   10 * time.Second,
 				KeepAlive: 30 * time.Second,
-			}).DialContext,
+			}),
 			MaxIdleConns:          100,
 			MaxIdleConnsPerHost:   10,
 			IdleConnTimeout:       90 * time.Second,
@@ -57,65 +158,87 @@ func newHTTPClient() *http.Client {
 			ExpectContinueTimeout: 1 * time.Second,
 			// 禁用 HTTP/2，某些网站对 HTTP/2 支持不好
 			ForceAttemptHTTP2: false,
-		},
-	}
+		}))),
+	}, nil
 }
 
-// fetchHTML 通用的 HTML 抓取方法
-func fetchHTML(ctx context.Context, client *http.Client, targetURL string, headers map[string]string) (*goquery.Document, error) {
-	fmt.Fprintf(os.Stderr, "🌐 [Request] GET %s\n", targetURL)
+// maxHTMLBytes 页面最大读取长度，超出部分丢弃
+const maxHTMLBytes = 8 << 20
+
+// fetchHTML 通用的 HTML 抓取方法，按浏览器配置模拟页面导航请求
+func fetchHTML(ctx context.Context, do func(*http.Request) (*http.Response, error), profile *browser.Profile, targetURL string, headers map[string]string) (*goquery.Document, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
 
-	// 设置更完整的浏览器 Headers，模拟真实浏览器
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
-	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
+	profile.Apply(req.Header, browser.KindDocument)
 	req.Header.Set("Cache-Control", "max-age=0")
 
-	// 设置自定义 Headers (会覆盖上面的默认值)
+	// 设置自定义 Headers (会覆盖上面的默认值)，空值忽略
 	for k, v := range headers {
-		req.Header.Set(k, v)
+		if v != "" {
+			req.Header.Set(k, v)
+		}
 	}
 
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", core.ErrRequestFailed, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &core.StatusError{Code: resp.StatusCode}
 	}
 
-	// 处理 gzip 压缩
-	var reader io.Reader = resp.Body
-	if resp.Header.Get("Content-Encoding") == "gzip" {
-		gzReader, err := gzip.NewReader(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("create gzip reader failed: %w", err)
-		}
-		defer gzReader.Close()
-		reader = gzReader
+	// 压缩已由 Transport 解开，这里只需转换字符编码 (部分站点仍使用 GBK/GB2312)
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxHTMLBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
 	}
 
-	doc, err := goquery.NewDocumentFromReader(reader)
+	_, span := tracing.Start(ctx, "parse_html")
+	defer span.End()
+
+	content, charsetName, err := utils.DecodeCharset(content, resp.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("parse HTML failed: %w", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	if charsetName != "utf-8" {
+		logger.DebugContext(ctx, "html charset converted", "charset", charsetName)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil {
+		span.RecordError(err)
+		return nil, &core.DecodeError{Op: "parse HTML", Err: err}
 	}
 
 	return doc, nil
 }
 
+// decodeJSON 解析 API 响应，解析耗时单独记录为 Span
+func decodeJSON(ctx context.Context, r io.Reader, v any) error {
+	_, span := tracing.Start(ctx, "parse_json")
+	defer span.End()
+
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		span.RecordError(err)
+		return &core.DecodeError{Op: "decode JSON", Err: err}
+	}
+	return nil
+}
+
+// browsePage 返回浏览模式下的页码 (从 1 开始)
+func browsePage(opts core.SearchOptions) int {
+	if opts.Page < 1 {
+		return 1
+	}
+	return opts.Page
+}
+
 // applyImageProxy 如果配置了 IMAGE_PROXY_URL 环境变量，则对图片 URL 进行代理处理
 // 支持占位符: {URL} 或 {SOURCE_URL} 表示原图地址, {REFERER} 表示 Referer
 func applyImageProxy(imgURL, referer string) string {
@@ -134,12 +257,28 @@ func applyImageProxy(imgURL, referer string) string {
 	return result
 }
 
+// proxiedImage 对图片应用代理，返回最终地址及备用地址 (使用代理时备用地址为原图直链)
+func proxiedImage(imgURL, referer string) (string, []string) {
+	finalURL := applyImageProxy(imgURL, referer)
+	if finalURL == imgURL {
+		return imgURL, nil
+	}
+	return finalURL, []string{imgURL}
+}
+
 // ============ 趣斗图 (Qudoutu) ============
 
 type QudoutuSource struct {
 	BaseSource
 }
 
+var qudoutuLayout = pageLayout{
+	container: "div.item-grid",
+	items:     "div.item-grid ul li",
+	image:     "a.Link img[src]",
+	empty:     []string{"div.empty"},
+}
+
 func NewQudoutu() *QudoutuSource {
 	return &QudoutuSource{
 		BaseSource: BaseSource{
@@ -148,20 +287,24 @@ func NewQudoutu() *QudoutuSource {
 			description: "从 qudoutu.cn 搜索表情包",
 			requireAuth: false,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://www.qudoutu.cn",
+				Referer: "https://www.qudoutu.cn/",
+			},
+			capabilities: core.Capabilities{
+				HonorsLimit: true,
+				NeedsProxy:  true,
+				Languages:   []string{"zh"},
+				Formats:     []string{"gif", "jpg", "png"},
+			},
 		},
 	}
 }
 
 func (s *QudoutuSource) Search(ctx context.Context, keyword string, opts core.SearchOptions) ([]core.Meme, error) {
-	searchURL := fmt.Sprintf(
-		"https://www.qudoutu.cn/search/?keyword=%s",
-		url.QueryEscape(keyword),
-	)
+	searchURL := s.endpoint.url("/search/?keyword=" + url.QueryEscape(keyword))
 
-	doc, err := fetchHTML(ctx, s.client, searchURL, map[string]string{
-		"Referer":        "https://www.qudoutu.cn/",
-		"Sec-Fetch-Site": "same-origin",
-	})
+	doc, err := s.fetchDocument(ctx, searchURL)
 	if err != nil {
 		return nil, err
 	}
@@ -169,7 +312,7 @@ func (s *QudoutuSource) Search(ctx context.Context, keyword string, opts core.Se
 	var memes []core.Meme
 	// 更精确地定位搜索结果区域：item-grid 下的 ul 中的 li
 	// 如果精确选择器找不到，回退到通用选择器
-	results := doc.Find("div.item-grid ul li")
+	results := doc.Find(qudoutuLayout.items)
 	if results.Length() == 0 {
 		// 回退到通用选择器，但只选择包含 a.Link 的 li
 		results = doc.Find("li").Has("a.Link")
@@ -183,7 +326,7 @@ func (s *QudoutuSource) Search(ctx context.Context, keyword string, opts core.Se
 
 		// 处理相对路径
 		if strings.HasPrefix(imgURL, "/") {
-			imgURL = "https://www.qudoutu.cn" + imgURL
+			imgURL = s.endpoint.url(imgURL)
 		}
 
 		imgURL = core.NormalizeURL(imgURL)
@@ -197,16 +340,22 @@ func (s *QudoutuSource) Search(ctx context.Context, keyword string, opts core.Se
 		}
 
 		// 如果需要，应用图片代理
-		finalURL := applyImageProxy(imgURL, "https://www.qudoutu.cn/")
+		finalURL, alternates := proxiedImage(imgURL, s.endpoint.Referer)
 
 		memes = append(memes, core.Meme{
-			Title:    title,
-			URL:      finalURL,
-			Platform: s.id,
-			Format:   core.DetectImageFormat(imgURL),
+			Title:      title,
+			URL:        finalURL,
+			Platform:   s.id,
+			Format:     core.DetectImageFormat(imgURL),
+			Thumbnail:  finalURL,
+			Alternates: alternates,
 		})
 	})
 
+	if err := s.checkLayout(ctx, doc, searchURL, qudoutuLayout, results.Length(), len(memes)); err != nil {
+		return nil, err
+	}
+
 	if opts.Limit > 0 && len(memes) > opts.Limit {
 		memes = memes[:opts.Limit]
 	}
@@ -220,6 +369,13 @@ type DoutulaSource struct {
 	BaseSource
 }
 
+var doutulaLayout = pageLayout{
+	container: "div.random_picture",
+	items:     "a.col-xs-6.col-md-2",
+	image:     "img.image_dtb[data-original]",
+	empty:     []string{"div.random_picture div.page-content > p"}, // 条目中的标题在 <a> 内，直接子元素 <p> 只有提示文字
+}
+
 func NewDoutula() *DoutulaSource {
 	return &DoutulaSource{
 		BaseSource: BaseSource{
@@ -228,47 +384,67 @@ func NewDoutula() *DoutulaSource {
 			description: "从 doutupk.com 搜索表情包",
 			requireAuth: false,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://www.doutupk.com",
+				Referer: "https://www.doutupk.com/",
+			},
+			capabilities: core.Capabilities{
+				HonorsLimit: true,
+				BrowseModes: []core.BrowseMode{core.BrowseLatest},
+				Languages:   []string{"zh"},
+				Formats:     []string{"gif", "jpg", "png"},
+			},
 		},
 	}
 }
 
 func (s *DoutulaSource) Search(ctx context.Context, keyword string, opts core.SearchOptions) ([]core.Meme, error) {
-	searchURL := fmt.Sprintf(
-		"https://www.doutupk.com/search?keyword=%s",
-		url.QueryEscape(keyword),
-	)
+	searchURL := s.endpoint.url("/search?keyword=" + url.QueryEscape(keyword))
 
-	doc, err := fetchHTML(ctx, s.client, searchURL, map[string]string{
-		"Referer": "https://www.doutupk.com/",
-	})
+	return s.fetchList(ctx, searchURL, opts)
+}
+
+// Latest 浏览最新表情包
+func (s *DoutulaSource) Latest(ctx context.Context, opts core.SearchOptions) ([]core.Meme, error) {
+	return s.fetchList(ctx, s.endpoint.url(fmt.Sprintf("/photo/list/?page=%d", browsePage(opts))), opts)
+}
+
+// fetchList 抓取并解析斗图啦的表情包列表页 (搜索页与列表页结构相同)
+func (s *DoutulaSource) fetchList(ctx context.Context, pageURL string, opts core.SearchOptions) ([]core.Meme, error) {
+	doc, err := s.fetchDocument(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
 
 	var memes []core.Meme
-	doc.Find("a.col-xs-6.col-md-2").Each(func(i int, sel *goquery.Selection) {
+	items := doc.Find(doutulaLayout.items)
+	items.Each(func(i int, sel *goquery.Selection) {
 		title := strings.TrimSpace(sel.Find("p").Text())
 		if title == "" {
 			title = "斗图啦"
 		}
 
-		imgURL, exists := sel.Find("img.image_dtb").Attr("data-original")
-		if !exists || imgURL == "" {
+		// data-original 为原图，data-backup 为备用 CDN
+		img := sel.Find("img.image_dtb")
+		urls := core.CollectImageURLs(img.AttrOr("data-original", ""), img.AttrOr("data-backup", ""))
+		if len(urls) == 0 {
 			return
 		}
 
-		// 强制 HTTPS
-		imgURL = core.NormalizeURL(imgURL)
-		if core.IsValidImageURL(imgURL) {
-			memes = append(memes, core.Meme{
-				Title:    title,
-				URL:      imgURL,
-				Platform: s.id,
-				Format:   core.DetectImageFormat(imgURL),
-			})
-		}
+		memes = append(memes, core.Meme{
+			Title:      title,
+			URL:        urls[0],
+			Platform:   s.id,
+			Format:     core.DetectImageFormat(urls[0]),
+			Thumbnail:  urls[0],
+			Alternates: urls[1:],
+		})
 	})
 
+	if err := s.checkLayout(ctx, doc, pageURL, doutulaLayout, items.Length(), len(memes)); err != nil {
+		return nil, err
+	}
+
 	if opts.Limit > 0 && len(memes) > opts.Limit {
 		memes = memes[:opts.Limit]
 	}
@@ -282,6 +458,13 @@ type PdanSource struct {
 	BaseSource
 }
 
+var pdanLayout = pageLayout{
+	container: "main.site-main",
+	items:     "a.imageLink.image.loading",
+	image:     "img",
+	empty:     []string{"body.search-no-results", ".no-results", "section.not-found"},
+}
+
 func NewPdan() *PdanSource {
 	return &PdanSource{
 		BaseSource: BaseSource{
@@ -290,25 +473,41 @@ func NewPdan() *PdanSource {
 			description: "从 pdan.com.cn 搜索表情包",
 			requireAuth: false,
 			client:      newHTTPClient(),
+			endpoint: Endpoint{
+				BaseURL: "https://pdan.com.cn",
+				Referer: "https://pdan.com.cn/",
+			},
+			capabilities: core.Capabilities{
+				HonorsLimit: true,
+				BrowseModes: []core.BrowseMode{core.BrowseLatest},
+				Languages:   []string{"zh"},
+				Formats:     []string{"gif", "jpg", "png"},
+			},
 		},
 	}
 }
 
 func (s *PdanSource) Search(ctx context.Context, keyword string, opts core.SearchOptions) ([]core.Meme, error) {
-	searchURL := fmt.Sprintf(
-		"https://pdan.com.cn/?s=%s",
-		url.QueryEscape(keyword),
-	)
+	searchURL := s.endpoint.url("/?s=" + url.QueryEscape(keyword))
 
-	doc, err := fetchHTML(ctx, s.client, searchURL, map[string]string{
-		"Referer": "https://pdan.com.cn/",
-	})
+	return s.fetchList(ctx, searchURL, opts)
+}
+
+// Latest 浏览最新表情包
+func (s *PdanSource) Latest(ctx context.Context, opts core.SearchOptions) ([]core.Meme, error) {
+	return s.fetchList(ctx, s.endpoint.url(fmt.Sprintf("/page/%d/", browsePage(opts))), opts)
+}
+
+// fetchList 抓取并解析胖哒的表情包列表页 (搜索页与首页列表结构相同)
+func (s *PdanSource) fetchList(ctx context.Context, pageURL string, opts core.SearchOptions) ([]core.Meme, error) {
+	doc, err := s.fetchDocument(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
 
 	var memes []core.Meme
-	doc.Find("a.imageLink.image.loading").Each(func(i int, sel *goquery.Selection) {
+	items := doc.Find(pdanLayout.items)
+	items.Each(func(i int, sel *goquery.Selection) {
 		// 按优先级获取标题
 		title := sel.AttrOr("title", "")
 		if title == "" {
@@ -321,27 +520,27 @@ func (s *PdanSource) Search(ctx context.Context, keyword string, opts core.Searc
 			title = "胖哒"
 		}
 
-		// 优先 data-src，其次 src
-		imgURL := sel.Find("img").AttrOr("data-src", "")
-		if imgURL == "" {
-			imgURL = sel.Find("img").AttrOr("src", "")
-		}
-
-		if imgURL == "" {
+		// 优先 data-src (懒加载的原图)，其次 src；src 已加载时通常是较小的预览图
+		img := sel.Find("img")
+		urls := core.CollectImageURLs(img.AttrOr("data-src", ""), img.AttrOr("src", ""))
+		if len(urls) == 0 {
 			return
 		}
 
-		imgURL = core.NormalizeURL(imgURL)
-		if core.IsValidImageURL(imgURL) {
-			memes = append(memes, core.Meme{
-				Title:    title,
-				URL:      imgURL,
-				Platform: s.id,
-				Format:   core.DetectImageFormat(imgURL),
-			})
-		}
+		memes = append(memes, core.Meme{
+			Title:      title,
+			URL:        urls[0],
+			Platform:   s.id,
+			Format:     core.DetectImageFormat(urls[0]),
+			Thumbnail:  urls[len(urls)-1],
+			Alternates: urls[1:],
+		})
 	})
 
+	if err := s.checkLayout(ctx, doc, pageURL, pdanLayout, items.Length(), len(memes)); err != nil {
+		return nil, err
+	}
+
 	if opts.Limit > 0 && len(memes) > opts.Limit {
 		memes = memes[:opts.Limit]
 	}
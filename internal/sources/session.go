@@ -0,0 +1,343 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shadow/meme/internal/browser"
+	"github.com/shadow/meme/internal/core"
+)
+
+// SessionConfig 源的会话配置：Cookie 持久化与首页预热
+// 每个源都有独立的 Cookie Jar，站点下发的 Cookie 在后续请求中自动携带
+type SessionConfig struct {
+	// Dir Cookie 保存目录，每个源一个文件 (<id>-cookies.json)；为空时只保存在内存中
+	Dir string `json:"dir,omitempty" yaml:"dir"`
+	// WarmUp 首次请求前先访问站点首页获取会话 Cookie，遇到 403 时重新访问并重试一次
+	// 为空表示未配置，按源显式设为 false 时可关闭全局开启的预热
+	WarmUp *bool `json:"warm_up,omitempty" yaml:"warm_up"`
+}
+
+// warmUpRetryAfter 预热失败后，在这段时间内不再访问首页
+const warmUpRetryAfter = time.Minute
+
+// IsZero 是否未配置
+func (c SessionConfig) IsZero() bool {
+	return c.Dir == "" && c.WarmUp == nil
+}
+
+// WarmUpEnabled 是否开启预热
+func (c SessionConfig) WarmUpEnabled() bool {
+	return c.WarmUp != nil && *c.WarmUp
+}
+
+// merge 用 override 中已配置的字段覆盖 c
+func (c SessionConfig) merge(override SessionConfig) SessionConfig {
+	if override.Dir != "" {
+		c.Dir = override.Dir
+	}
+	if override.WarmUp != nil {
+		c.WarmUp = override.WarmUp
+	}
+	return c
+}
+
+// loadSessions 从环境变量读取全局与按源的会话配置
+// 全局：MEME_SESSION_DIR、MEME_WARMUP；按源：<ID>_SESSION_DIR、<ID>_WARMUP
+func loadSessions() (SessionConfig, map[string]SessionConfig, error) {
+	global, err := loadSessionEnv("MEME_")
+	if err != nil {
+		return SessionConfig{}, nil, fmt.Errorf("session: %w", err)
+	}
+
+	perSource := make(map[string]SessionConfig)
+	for _, id := range endpointSourceIDs {
+		c, err := loadSessionEnv(strings.ToUpper(id) + "_")
+		if err != nil {
+			return SessionConfig{}, nil, fmt.Errorf("%s session: %w", id, err)
+		}
+		if !c.IsZero() {
+			perSource[id] = c
+		}
+	}
+	return global, perSource, nil
+}
+
+func loadSessionEnv(prefix string) (SessionConfig, error) {
+	c := SessionConfig{Dir: strings.TrimSpace(os.Getenv(prefix + "SESSION_DIR"))}
+	if raw := strings.TrimSpace(os.Getenv(prefix + "WARMUP")); raw != "" {
+		warmUp, err := strconv.ParseBool(raw)
+		if err != nil {
+			return SessionConfig{}, fmt.Errorf("invalid %sWARMUP %q", prefix, raw)
+		}
+		c.WarmUp = &warmUp
+	}
+	return c, nil
+}
+
+// ============ 会话 ============
+
+// session 源的会话状态
+type session struct {
+	config SessionConfig
+	jar    *persistentJar
+
+	mu       sync.Mutex // 串行化预热请求
+	warmedAt time.Time
+	failedAt time.Time // 最近一次预热失败的时间，成功后清零
+}
+
+// SetSession 为源创建 Cookie Jar (配置了目录时从文件加载已保存的 Cookie) 并设置预热策略
+func (b *BaseSource) SetSession(c SessionConfig) error {
+	path := ""
+	if c.Dir != "" {
+		path = filepath.Join(c.Dir, b.id+"-cookies.json")
+	}
+	jar, err := newPersistentJar(path)
+	if err != nil {
+		return err
+	}
+	b.session = &session{config: c, jar: jar}
+	b.client.Jar = jar
+	return nil
+}
+
+// Session 返回源的会话配置
+func (b *BaseSource) Session() SessionConfig {
+	if b.session == nil {
+		return SessionConfig{}
+	}
+	return b.session.config
+}
+
+// do 发送请求：启用预热时首次请求前先访问站点首页，响应 403 时重新预热并重试一次
+func (b *BaseSource) do(req *http.Request) (*http.Response, error) {
+	if !b.Session().WarmUpEnabled() || req.Body != nil {
+		return b.client.Do(req)
+	}
+	ctx := req.Context()
+	b.warmUp(ctx, time.Time{})
+
+	// Client 会把 Jar 中的 Cookie 写入 req 的请求头，重试需使用发送前的副本，才能带上重新预热后的 Cookie
+	retry := req.Clone(ctx)
+	start := time.Now()
+	resp, err := b.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusForbidden {
+		return resp, err
+	}
+
+	logger.InfoContext(ctx, "request forbidden, warming up session again", "source", b.id)
+	if !b.warmUp(ctx, start) {
+		// 没能拿到新会话，重试也是 403
+		return resp, nil
+	}
+	resp.Body.Close()
+	return b.client.Do(retry)
+}
+
+// warmUp 访问站点首页，让站点下发会话 Cookie；上次预热晚于 staleBefore 时跳过，返回会话是否可用
+// 并发请求同时触发时只预热一次；预热失败只记录日志，warmUpRetryAfter 内不再访问首页
+func (b *BaseSource) warmUp(ctx context.Context, staleBefore time.Time) bool {
+	s := b.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.warmedAt.IsZero() && !s.warmedAt.Before(staleBefore) {
+		return true
+	}
+	if !s.failedAt.IsZero() && time.Since(s.failedAt) < warmUpRetryAfter {
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.endpoint.BaseURL+"/", nil)
+	if err != nil {
+		return false
+	}
+	b.applyHeaders(req, b.profile(), browser.KindDocument)
+	resp, err := b.client.Do(req)
+	if err == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTMLBytes))
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			err = &core.StatusError{Code: resp.StatusCode}
+		}
+	}
+	if err != nil {
+		s.failedAt = time.Now()
+		logger.WarnContext(ctx, "session warm-up failed", "source", b.id, "retry_after", warmUpRetryAfter, "error", err)
+		return false
+	}
+
+	s.warmedAt = time.Now()
+	s.failedAt = time.Time{}
+	logger.DebugContext(ctx, "session warmed up", "source", b.id, "status", resp.StatusCode)
+	return true
+}
+
+// ============ Cookie 持久化 ============
+
+// savedCookie 保存到文件中的 Cookie，URL 为下发该 Cookie 的请求地址 (决定默认的域与路径)
+type savedCookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+// persistentJar 在标准库 Cookie Jar 的基础上记录站点下发的 Cookie，path 非空时每次变化后写入文件
+// 会话 Cookie (未设置过期时间) 同样保存，重启后仍可复用预热得到的会话
+type persistentJar struct {
+	*cookiejar.Jar
+	path string
+
+	mu      sync.Mutex
+	cookies map[string]savedCookie // 键为 域|路径|名称
+}
+
+func newPersistentJar(path string) (*persistentJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	j := &persistentJar{Jar: jar, path: path, cookies: make(map[string]savedCookie)}
+	if path == "" {
+		return j, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cookie jar failed: %w", err)
+	}
+	var saved []savedCookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("decode cookie jar %s failed: %w", path, err)
+	}
+
+	now := time.Now()
+	for _, c := range saved {
+		u, err := url.Parse(c.URL)
+		if err != nil || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			continue
+		}
+		j.Jar.SetCookies(u, []*http.Cookie{c.cookie()})
+		j.cookies[c.key(u)] = c
+	}
+	return j, nil
+}
+
+// SetCookies 记录站点下发的 Cookie，已过期或被删除的 Cookie 同时从文件中移除
+func (j *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		saved := savedCookie{
+			URL:      u.Scheme + "://" + u.Host + u.Path,
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		switch {
+		case c.MaxAge > 0:
+			saved.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		case c.MaxAge == 0 && !c.Expires.IsZero():
+			saved.Expires = c.Expires
+		}
+
+		key := saved.key(u)
+		if c.MaxAge < 0 || (!saved.Expires.IsZero() && saved.Expires.Before(now)) {
+			delete(j.cookies, key)
+			continue
+		}
+		j.cookies[key] = saved
+	}
+
+	if err := j.save(); err != nil {
+		logger.Warn("save cookie jar failed", "path", j.path, "error", err)
+	}
+}
+
+// Len 已记录的 Cookie 数量
+func (j *persistentJar) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.cookies)
+}
+
+// save 写入文件 (权限 0600)，先写临时文件再重命名，避免中断时文件损坏
+func (j *persistentJar) save() error {
+	if j.path == "" {
+		return nil
+	}
+	keys := make([]string, 0, len(j.cookies))
+	for key := range j.cookies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	saved := make([]savedCookie, 0, len(keys))
+	for _, key := range keys {
+		saved = append(saved, j.cookies[key])
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (c savedCookie) cookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		Expires:  c.Expires,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+}
+
+// key 未设置 Domain 的 Cookie 只属于下发它的主机
+func (c savedCookie) key(u *url.URL) string {
+	domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	return domain + "|" + c.Path + "|" + c.Name
+}
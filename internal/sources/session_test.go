@@ -0,0 +1,207 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+)
+
+// sessionSite 首页下发会话 Cookie，搜索请求没有有效会话时返回 403
+type sessionSite struct {
+	upstream http.Handler
+
+	mu         sync.Mutex
+	session    string // 当前有效的会话值
+	homeStatus int    // 非零时首页返回该状态码且不下发 Cookie
+	homes      int    // 首页访问次数
+	denied     int    // 被拒绝的请求数
+}
+
+func (s *sessionSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/" && r.URL.RawQuery == "" {
+		s.homes++
+		if s.homeStatus != 0 {
+			w.WriteHeader(s.homeStatus)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: s.session, Path: "/", MaxAge: 3600})
+		w.Write([]byte("<html></html>"))
+		return
+	}
+	if c, err := r.Cookie("sid"); err != nil || c.Value != s.session {
+		s.denied++
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.upstream.ServeHTTP(w, r)
+}
+
+func (s *sessionSite) counts() (homes, denied int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.homes, s.denied
+}
+
+func newSessionSite(t *testing.T) (*sessionSite, string) {
+	t.Helper()
+	site := &sessionSite{upstream: mockUpstream(), session: "v1"}
+	return site, newUpstreamServer(t, site, false).URL
+}
+
+// warmUpOn、warmUpOff 取地址后用于设置 SessionConfig.WarmUp
+var (
+	warmUpOn  = true
+	warmUpOff = false
+)
+
+func newSessionPdan(t *testing.T, base string, config SessionConfig) *PdanSource {
+	t.Helper()
+	src := NewPdan()
+	src.SetEndpoint(Endpoint{BaseURL: base})
+	if err := src.SetSession(config); err != nil {
+		t.Fatalf("SetSession: %v", err)
+	}
+	return src
+}
+
+func TestSessionWarmUp(t *testing.T) {
+	site, base := newSessionSite(t)
+	src := newSessionPdan(t, base, SessionConfig{WarmUp: &warmUpOn})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := src.Search(ctx, "猫", core.SearchOptions{}); err != nil {
+			t.Fatalf("search %d: %v", i, err)
+		}
+	}
+	if homes, denied := site.counts(); homes != 1 || denied != 0 {
+		t.Errorf("homes = %d, denied = %d; want one warm-up and no 403", homes, denied)
+	}
+
+	// 站点让会话失效后，下一次请求收到 403，重新预热后重试成功
+	site.mu.Lock()
+	site.session = "v2"
+	site.mu.Unlock()
+	if _, err := src.Search(ctx, "猫", core.SearchOptions{}); err != nil {
+		t.Fatalf("search after session expired: %v", err)
+	}
+	if homes, denied := site.counts(); homes != 2 || denied != 1 {
+		t.Errorf("homes = %d, denied = %d; want a second warm-up after one 403", homes, denied)
+	}
+}
+
+func TestSessionWarmUpFailure(t *testing.T) {
+	site, base := newSessionSite(t)
+	site.homeStatus = http.StatusServiceUnavailable
+	src := newSessionPdan(t, base, SessionConfig{WarmUp: &warmUpOn})
+	ctx := context.Background()
+
+	// 首页不可用时只尝试一次，之后的请求不再访问首页，403 也不重试
+	for i := 0; i < 3; i++ {
+		if _, err := src.Search(ctx, "猫", core.SearchOptions{}); err == nil || !strings.Contains(err.Error(), "403") {
+			t.Fatalf("search %d: err = %v, want 403", i, err)
+		}
+	}
+	if homes, denied := site.counts(); homes != 1 || denied != 3 {
+		t.Errorf("homes = %d, denied = %d; want one warm-up attempt and three 403", homes, denied)
+	}
+
+	// 超过退避时间后重新预热
+	site.mu.Lock()
+	site.homeStatus = 0
+	site.mu.Unlock()
+	src.session.mu.Lock()
+	src.session.failedAt = time.Now().Add(-warmUpRetryAfter)
+	src.session.mu.Unlock()
+	if _, err := src.Search(ctx, "猫", core.SearchOptions{}); err != nil {
+		t.Fatalf("search after backoff: %v", err)
+	}
+	if homes, _ := site.counts(); homes != 2 {
+		t.Errorf("homes = %d, want a second warm-up after backoff", homes)
+	}
+}
+
+func TestSessionWithoutWarmUp(t *testing.T) {
+	site, base := newSessionSite(t)
+	src := newSessionPdan(t, base, SessionConfig{})
+
+	_, err := src.Search(context.Background(), "猫", core.SearchOptions{})
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("err = %v, want 403", err)
+	}
+	if homes, _ := site.counts(); homes != 0 {
+		t.Errorf("homes = %d, want no warm-up", homes)
+	}
+}
+
+func TestSessionPersisted(t *testing.T) {
+	site, base := newSessionSite(t)
+	dir := filepath.Join(t.TempDir(), "sessions")
+
+	first := newSessionPdan(t, base, SessionConfig{Dir: dir, WarmUp: &warmUpOn})
+	if _, err := first.Search(context.Background(), "猫", core.SearchOptions{}); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	path := filepath.Join(dir, "pdan-cookies.json")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("cookie jar not saved: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("cookie jar mode = %o, want 600", perm)
+	}
+
+	// 重启后从文件恢复会话，不需要再次预热
+	second := newSessionPdan(t, base, SessionConfig{Dir: dir})
+	if second.session.jar.Len() != 1 {
+		t.Errorf("restored %d cookies, want 1", second.session.jar.Len())
+	}
+	if _, err := second.Search(context.Background(), "猫", core.SearchOptions{}); err != nil {
+		t.Fatalf("search with restored session: %v", err)
+	}
+	if homes, denied := site.counts(); homes != 1 || denied != 0 {
+		t.Errorf("homes = %d, denied = %d; want restored session to be reused", homes, denied)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o600)
+	if err := NewPdan().SetSession(SessionConfig{Dir: dir}); err == nil {
+		t.Error("want error for corrupted cookie jar")
+	}
+}
+
+func TestLoadSessions(t *testing.T) {
+	t.Setenv("MEME_SESSION_DIR", "/var/lib/meme")
+	t.Setenv("MEME_WARMUP", "1")
+	t.Setenv("PDAN_WARMUP", "0")
+
+	global, perSource, err := loadSessions()
+	if err != nil {
+		t.Fatalf("loadSessions: %v", err)
+	}
+	if got := global.merge(perSource["doutula"]); got.Dir != "/var/lib/meme" || !got.WarmUpEnabled() {
+		t.Errorf("doutula session = %+v, want global warm-up", got)
+	}
+	// 按源显式关闭时覆盖全局开启
+	if got := global.merge(perSource["pdan"]); got.WarmUpEnabled() {
+		t.Errorf("pdan session = %+v, want no warm-up", got)
+	}
+	if got := (SessionConfig{}).merge(SessionConfig{WarmUp: &warmUpOff}); got.WarmUpEnabled() || got.IsZero() {
+		t.Errorf("explicit off = %+v, want configured but disabled", got)
+	}
+
+	t.Setenv("MEME_WARMUP", "sometimes")
+	if _, _, err := loadSessions(); err == nil {
+		t.Error("want error for invalid MEME_WARMUP")
+	}
+}
@@ -0,0 +1,517 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/shadow/meme/internal/browser"
+	"github.com/shadow/meme/internal/cassette"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/mockupstream"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// transportSource 可替换 Transport 的源
+type transportSource interface {
+	core.Source
+	SetTransport(rt http.RoundTripper)
+	Transport() http.RoundTripper
+}
+
+// douyinVolatileParams 抖音请求中每次都会变化的参数，回放时不参与匹配
+var douyinVolatileParams = []string{"X-Bogus", "msToken", "verifyFp", "fp"}
+
+// cassettes 同一测试中共享的磁带，按名称区分
+// 默认回放 testdata/cassettes/<name>.json，文件不存在时用例失败；
+// MEME_CASSETTE_MODE=record 时请求真实网站，测试结束后覆盖该文件；
+// 同时设置 MEME_CASSETTE_UPSTREAM=mock 时改为请求进程内的模拟上游 (internal/mockupstream)，
+// 磁带中仍记录各站点的原始 URL，并在 origin 字段注明 mockupstream
+type cassettes struct {
+	loaded   map[string]*cassette.Transport
+	upstream *url.URL // 录制模拟上游时的地址，为空表示请求真实网站
+}
+
+func newCassettes(t *testing.T) *cassettes {
+	c := &cassettes{loaded: make(map[string]*cassette.Transport)}
+	if recordingMock() {
+		// 磁带在子测试间共享，模拟上游需在整个测试期间保持运行；结果数超过抖音的单页数量，第二页才有数据
+		u, _ := url.Parse(newUpstreamServer(t, mockupstream.New(mockupstream.Options{Total: 12, Seed: 1}), false).URL)
+		c.upstream = u
+	}
+	t.Cleanup(func() {
+		for name, rt := range c.loaded {
+			if err := rt.Save(); err != nil {
+				t.Errorf("save cassette %s: %v", name, err)
+			}
+		}
+	})
+	return c
+}
+
+// recordingMock 是否从模拟上游录制磁带
+func recordingMock() bool {
+	return cassette.ModeFromEnv() == cassette.ModeRecord && os.Getenv("MEME_CASSETTE_UPSTREAM") == "mock"
+}
+
+// use 让源通过指定磁带收发请求，ignoredParams 为匹配时忽略的查询参数
+func (c *cassettes) use(t *testing.T, src transportSource, name string, ignoredParams ...string) {
+	t.Helper()
+
+	rt, ok := c.loaded[name]
+	if !ok {
+		path := filepath.Join("testdata", "cassettes", name+".json")
+		mode := cassette.ModeFromEnv()
+		if mode != cassette.ModeRecord {
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("cassette %s is missing, record it with make record-fixtures or make record-fixtures-mock", path)
+			}
+		}
+		real, origin := src.Transport(), "live"
+		if c.upstream != nil {
+			real, origin = redirectTransport{base: real, target: c.upstream}, "mockupstream"
+		}
+		var err error
+		rt, err = cassette.New(path, mode,
+			cassette.WithRealTransport(real),
+			cassette.WithIgnoredParams(ignoredParams...),
+			cassette.WithOrigin(origin),
+		)
+		if err != nil {
+			t.Fatalf("load cassette %s: %v", name, err)
+		}
+		c.loaded[name] = rt
+	}
+	src.SetTransport(rt)
+}
+
+// redirectTransport 将请求改发到 target，只替换协议与主机，路径和查询串保持不变
+type redirectTransport struct {
+	base   http.RoundTripper
+	target *url.URL
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = r.target.Scheme, r.target.Host
+	req.Host = ""
+	return r.base.RoundTrip(req)
+}
+
+// testDouyin 回放与从模拟上游录制时使用占位 Cookie，录制真实网站时使用 DOUYIN_COOKIE，未设置时跳过
+func testDouyin(t *testing.T) transportSource {
+	cookie := "sessionid=test"
+	if cassette.ModeFromEnv() == cassette.ModeRecord && !recordingMock() {
+		cookie = os.Getenv("DOUYIN_COOKIE")
+		if cookie == "" {
+			t.Skip("recording douyin requires DOUYIN_COOKIE")
+		}
+	}
+	return NewDouyin(cookie)
+}
+
+func search(keyword string, page int) func(context.Context, core.Source) ([]core.Meme, error) {
+	return func(ctx context.Context, src core.Source) ([]core.Meme, error) {
+		return src.Search(ctx, keyword, core.SearchOptions{Page: page})
+	}
+}
+
+func latest() func(context.Context, core.Source) ([]core.Meme, error) {
+	return func(ctx context.Context, src core.Source) ([]core.Meme, error) {
+		return src.(core.Browser).Latest(ctx, core.SearchOptions{Page: 1})
+	}
+}
+
+// TestSourceParsing 回放 testdata/cassettes 中录制的响应，检查结果数量、首条结果的具体字段与所有结果的字段完整性
+// 仓库附带的磁带录制自本地模拟上游 (磁带的 origin 为 mockupstream，数据结构按各站点格式生成，内容并非真实数据)；
+// 从真实网站重新录制后，这里的期望值需按新数据更新
+func TestSourceParsing(t *testing.T) {
+	// 代理会改写 URL，测试中关闭
+	t.Setenv("IMAGE_PROXY_URL", "")
+	tapes := newCassettes(t)
+
+	static := func(src transportSource) func(*testing.T) transportSource {
+		return func(*testing.T) transportSource { return src }
+	}
+	douyinSticker := func(id, title string, width, height int, originExt string) core.Meme {
+		cdn := func(host, suffix, ext string) string {
+			return "https://" + host + ".douyinpic.com/tos-cn-i-0813/" + id + "~" + suffix + "." + ext
+		}
+		return core.Meme{
+			ID: "douyin-7300000000000" + id, Title: title, Platform: "douyin",
+			URL: cdn("p3-pc-sign", "animate", "gif"), Width: width, Height: height, Format: "gif",
+			Thumbnail: cdn("p3-pc-sign", "thumb", "jpg"),
+			Alternates: []string{
+				cdn("p9-pc-sign", "animate", "gif"),
+				cdn("p3-pc-sign", "origin", originExt), cdn("p9-pc-sign", "origin", originExt),
+				cdn("p3-pc-sign", "static", "png"), cdn("p9-pc-sign", "static", "png"),
+			},
+		}
+	}
+	tests := []struct {
+		name     string
+		cassette string
+		source   func(*testing.T) transportSource
+		ignore   []string
+		fetch    func(context.Context, core.Source) ([]core.Meme, error)
+		count    int
+		first    core.Meme
+	}{
+		{"sougou search", "sougou", static(NewSougou()), nil, search("猫", 1), 12, core.Meme{
+			Title: "猫 表情 1", Platform: "sougou",
+			URL: "https://img01.sogoucdn.com/mock/918044.png", Width: 280, Height: 240, Format: "png",
+			Thumbnail: "https://i01piccdn.sogoucdn.com/mock/918044.jpg",
+			Alternates: []string{
+				"https://www.example-origin.com/mock/918044.png",
+				"https://i01piccdn.sogoucdn.com/mock/918044.png",
+				"https://i01piccdn.sogoucdn.com/mock/918044.jpg",
+			},
+		}},
+		{"doutub search", "doutub", static(NewDoutub()), nil, search("猫", 1), 12, core.Meme{
+			Title: "猫 表情 1", Platform: "doutub",
+			URL: "https://image.doutub.com/mock/918044.png", Format: "png",
+			Thumbnail: "https://image.doutub.com/mock/918044.png",
+		}},
+		{"douyin search first page", "douyin", testDouyin, douyinVolatileParams, search("猫", 1), 10,
+			douyinSticker("918044", "[猫 表情 1]", 280, 240, "png")},
+		{"douyin search second page", "douyin", testDouyin, douyinVolatileParams, search("猫", 2), 2,
+			douyinSticker("182797", "[猫 表情 11]", 200, 280, "gif")},
+		{"qudoutu search", "qudoutu", static(NewQudoutu()), nil, search("猫", 1), 12, core.Meme{
+			Title: "猫 表情 1", Platform: "qudoutu",
+			URL: "https://www.qudoutu.cn/uploads/mock/918044.png", Format: "png",
+			Thumbnail: "https://www.qudoutu.cn/uploads/mock/918044.png",
+		}},
+		{"doutula search", "doutula", static(NewDoutula()), nil, search("猫", 1), 12, core.Meme{
+			Title: "猫 表情 1", Platform: "doutula",
+			URL: "https://img.doutupk.com/mock/918044.png", Format: "png",
+			Thumbnail:  "https://img.doutupk.com/mock/918044.png",
+			Alternates: []string{"https://ws1.sinaimg.cn/mock/918044.png"},
+		}},
+		{"doutula latest", "doutula", static(NewDoutula()), nil, latest(), 12, core.Meme{
+			Title: "最新 表情 1", Platform: "doutula",
+			URL: "https://img.doutupk.com/mock/180604.gif", Format: "gif",
+			Thumbnail:  "https://img.doutupk.com/mock/180604.gif",
+			Alternates: []string{"https://ws1.sinaimg.cn/mock/180604.gif"},
+		}},
+		{"pdan search", "pdan", static(NewPdan()), nil, search("猫", 1), 12, core.Meme{
+			Title: "猫 表情 1", Platform: "pdan",
+			URL: "https://pdan.com.cn/mock/918044.png", Format: "png",
+			Thumbnail: "https://pdan.com.cn/mock/918044.png",
+		}},
+		{"pdan latest", "pdan", static(NewPdan()), nil, latest(), 12, core.Meme{
+			Title: "最新 表情 1", Platform: "pdan",
+			URL: "https://pdan.com.cn/mock/180604.gif", Format: "gif",
+			Thumbnail: "https://pdan.com.cn/mock/180604.gif",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := tt.source(t)
+			tapes.use(t, src, tt.cassette, tt.ignore...)
+
+			memes, err := tt.fetch(context.Background(), src)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(memes) != tt.count {
+				t.Fatalf("parsed %d memes, want %d", len(memes), tt.count)
+			}
+			if got := memes[0]; !sameMeme(got, tt.first) {
+				t.Errorf("first meme = %+v\nwant %+v", got, tt.first)
+			}
+			for i, m := range memes {
+				if m.Title == "" || m.Thumbnail == "" || m.Platform != src.ID() {
+					t.Errorf("meme %d missing fields: %+v", i, m)
+				}
+				for _, raw := range append([]string{m.URL, m.Thumbnail}, m.Alternates...) {
+					if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+						t.Errorf("meme %d has invalid image URL %q", i, raw)
+					}
+				}
+			}
+		})
+	}
+}
+
+// sameMeme 逐字段比较，空切片与 nil 视为相同
+func sameMeme(a, b core.Meme) bool {
+	if !slices.Equal(a.Alternates, b.Alternates) {
+		return false
+	}
+	a.Alternates, b.Alternates = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// TestSourceResponses 异常与边界响应，响应体为按各站点格式手写的最小示例，并非录制数据
+func TestSourceResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  endpointTestSource
+		status  int
+		body    string
+		fetch   func(context.Context, core.Source) ([]core.Meme, error)
+		wantErr string // 非空时期望错误信息包含该片段，均为空时期望没有结果也没有错误
+		wantIs  error  // 非空时期望 errors.Is 成立
+	}{
+		{
+			name:    "doutub api error",
+			source:  NewDoutub(),
+			body:    `{"code":0,"msg":"关键词不合法","data":null}`,
+			fetch:   search("错误", 1),
+			wantErr: "api returned error code: 0",
+		},
+		{
+			name:   "douyin logged out",
+			source: NewDouyin("sessionid=test"),
+			body:   `{"status_code":8,"status_msg":"用户未登录"}`,
+			fetch:  search("登录", 1),
+			wantIs: core.ErrAuthRequired,
+		},
+		{
+			// 第一页 has_more 为假，第二页不再请求
+			name:   "douyin search past last page",
+			source: NewDouyin("sessionid=test"),
+			body:   `{"status_code":0,"emoticon_data":{"sticker_list":[],"has_more":0,"cursor":0}}`,
+			fetch: func(ctx context.Context, src core.Source) ([]core.Meme, error) {
+				if _, err := src.Search(ctx, "猫", core.SearchOptions{Page: 1}); err != nil {
+					return nil, err
+				}
+				return src.Search(ctx, "猫", core.SearchOptions{Page: 2})
+			},
+		},
+		{
+			// 条目还在，但图片改为 <picture>，取不到图片地址
+			name:   "qudoutu image markup changed",
+			source: NewQudoutu(),
+			body:   `<html><body><div class="item-grid"><ul><li><a class="Link" href="/detail/1.html"><picture><source srcset="/uploads/cat.webp"></picture></a><p>猫</p></li></ul></div></body></html>`,
+			fetch:  search("猫", 1),
+			wantIs: core.ErrLayoutChanged,
+		},
+		{
+			name:    "doutula latest unavailable",
+			source:  NewDoutula(),
+			status:  http.StatusServiceUnavailable,
+			body:    `<html><body><h1>503 Service Temporarily Unavailable</h1></body></html>`,
+			fetch:   latest(),
+			wantErr: "unexpected status code: 503",
+		},
+		{
+			// 页面提示没有结果，不视为结构变化
+			name:   "doutula search no results",
+			source: NewDoutula(),
+			body:   `<html><body><div class="random_picture"><div class="page-content text-center"><p class="text-muted">没有找到相关的表情，换个关键词试试吧</p></div></div></body></html>`,
+			fetch:  search("不存在", 1),
+		},
+		{
+			// 条目的 class 改名后找不到结果，也没有 "没有结果" 的提示
+			name:   "pdan layout changed",
+			source: NewPdan(),
+			body:   `<html><body class="search search-results"><main class="site-main"><div class="grid"><div class="grid-item"><a class="thumb-link" href="/cat.html" title="猫"><img data-src="/cat.gif"></a></div></div></main></body></html>`,
+			fetch:  search("猫", 1),
+			wantIs: core.ErrLayoutChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newUpstreamServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.body))
+			}), false)
+			tt.source.SetEndpoint(Endpoint{BaseURL: srv.URL})
+
+			memes, err := tt.fetch(context.Background(), tt.source)
+
+			switch {
+			case tt.wantIs != nil:
+				if !errors.Is(err, tt.wantIs) {
+					t.Fatalf("error = %v, want errors.Is %v", err, tt.wantIs)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(memes) != 0:
+				t.Fatalf("got %d memes, want none: %+v", len(memes), memes)
+			}
+		})
+	}
+}
+
+// endpointTestSource 测试中可同时替换 Transport 与站点地址的源
+type endpointTestSource interface {
+	transportSource
+	SetEndpoint(Endpoint)
+}
+
+func TestSourceEndpoint(t *testing.T) {
+	var got []string
+	upstream := mockUpstream()
+	srv := newUpstreamServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent")+" "+r.Header.Get("Referer"))
+		upstream.ServeHTTP(w, r)
+	}), false)
+
+	for _, src := range []endpointTestSource{NewSougou(), NewDoutub(), NewQudoutu(), NewDoutula(), NewPdan(), NewDouyin("sessionid=test")} {
+		t.Run(src.ID(), func(t *testing.T) {
+			got = nil
+			src.SetEndpoint(Endpoint{BaseURL: srv.URL + "/", Referer: srv.URL + "/ref", UserAgent: "meme-test"})
+
+			memes, err := src.Search(context.Background(), "猫", core.SearchOptions{})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(memes) == 0 {
+				t.Fatal("no memes returned")
+			}
+			if want := "meme-test " + srv.URL + "/ref"; len(got) != 1 || got[0] != want {
+				t.Errorf("requests = %q, want one with %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadEndpoints(t *testing.T) {
+	t.Setenv("PDAN_BASE_URL", "http://127.0.0.1:8089")
+	t.Setenv("DOUYIN_USER_AGENT", "Mozilla/5.0 Chrome/140.0.0.0")
+
+	endpoints, err := loadEndpoints()
+	if err != nil {
+		t.Fatalf("loadEndpoints: %v", err)
+	}
+	want := map[string]Endpoint{
+		"pdan":   {BaseURL: "http://127.0.0.1:8089"},
+		"douyin": {UserAgent: "Mozilla/5.0 Chrome/140.0.0.0"},
+	}
+	if !reflect.DeepEqual(endpoints, want) {
+		t.Errorf("endpoints = %+v, want %+v", endpoints, want)
+	}
+
+	t.Setenv("SOUGOU_BASE_URL", "pic.sogou.com")
+	if _, err := loadEndpoints(); err == nil {
+		t.Error("want error for base url without scheme")
+	}
+}
+
+func TestSourceBrowserProfiles(t *testing.T) {
+	var got []*http.Request
+	upstream := mockUpstream()
+	srv := newUpstreamServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r)
+		upstream.ServeHTTP(w, r)
+	}), false)
+
+	t.Run("round_robin", func(t *testing.T) {
+		got = nil
+		src := NewPdan()
+		src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+		if err := src.SetBrowser(BrowserConfig{Profiles: []string{"firefox-win", "chrome-win"}, Rotation: browser.RotationRoundRobin}); err != nil {
+			t.Fatalf("SetBrowser: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := src.Search(context.Background(), "猫", core.SearchOptions{}); err != nil {
+				t.Fatalf("search: %v", err)
+			}
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d requests, want 2", len(got))
+		}
+		if ua := got[0].Header.Get("User-Agent"); !strings.Contains(ua, "Firefox/") || got[0].Header.Get("sec-ch-ua") != "" {
+			t.Errorf("first request: User-Agent %q, sec-ch-ua %q", ua, got[0].Header.Get("sec-ch-ua"))
+		}
+		if got[1].Header.Get("sec-ch-ua-platform") != `"Windows"` {
+			t.Errorf("second request headers = %v", got[1].Header)
+		}
+	})
+
+	t.Run("douyin signature", func(t *testing.T) {
+		got = nil
+		src := NewDouyin("sessionid=test")
+		src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+		if err := src.SetBrowser(BrowserConfig{Profiles: []string{"edge-win"}}); err != nil {
+			t.Fatalf("SetBrowser: %v", err)
+		}
+		if _, err := src.Search(context.Background(), "猫", core.SearchOptions{}); err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got %d requests, want 1", len(got))
+		}
+		query := got[0].URL.Query()
+		if !strings.Contains(got[0].Header.Get("User-Agent"), "Edg/") || query.Get("browser_name") != "Edge" || query.Get("os_name") != "Windows" {
+			t.Errorf("User-Agent %q signed as %s on %s", got[0].Header.Get("User-Agent"), query.Get("browser_name"), query.Get("os_name"))
+		}
+	})
+
+	if err := NewPdan().SetBrowser(BrowserConfig{Profiles: []string{"netscape"}}); err == nil {
+		t.Error("want error for unknown profile")
+	}
+}
+
+func TestLoadBrowserConfigs(t *testing.T) {
+	t.Setenv("MEME_BROWSER_PROFILES", "all")
+	t.Setenv("MEME_BROWSER_ROTATION", "sticky")
+	t.Setenv("DOUYIN_BROWSER_PROFILES", "chrome-win")
+
+	global, perSource, err := loadBrowserConfigs()
+	if err != nil {
+		t.Fatalf("loadBrowserConfigs: %v", err)
+	}
+	if global.Rotation != browser.RotationSticky || len(perSource) != 1 {
+		t.Errorf("global = %+v, per source = %+v", global, perSource)
+	}
+	if got := global.merge(perSource["douyin"]); !reflect.DeepEqual(got.Profiles, []string{"chrome-win"}) || got.Rotation != browser.RotationSticky {
+		t.Errorf("merged douyin config = %+v", got)
+	}
+
+	t.Setenv("PDAN_BROWSER_ROTATION", "shuffle")
+	if _, _, err := loadBrowserConfigs(); err == nil {
+		t.Error("want error for invalid rotation")
+	}
+}
+
+func TestFetchHTMLDecoding(t *testing.T) {
+	// 模拟使用 GBK 编码并以 br 压缩返回页面的站点
+	upstream := mockUpstream()
+	srv := newUpstreamServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		upstream.ServeHTTP(rec, r)
+		page, err := simplifiedchinese.GBK.NewEncoder().Bytes(rec.Body.Bytes())
+		if err != nil {
+			t.Errorf("encode gbk: %v", err)
+		}
+		var buf bytes.Buffer
+		bw := brotli.NewWriter(&buf)
+		bw.Write(page)
+		bw.Close()
+
+		w.Header().Set("Content-Type", "text/html; charset=gb2312")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(buf.Bytes())
+	}), false)
+
+	src := NewPdan()
+	src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+	memes, err := src.Search(context.Background(), "猫", core.SearchOptions{})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(memes) == 0 || !strings.Contains(memes[0].Title, "猫") {
+		t.Errorf("memes = %+v, want decoded titles", memes)
+	}
+}
@@ -0,0 +1,238 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+)
+
+// PinError 证书链中没有与固定指纹匹配的公钥
+type PinError struct {
+	Host string   // 以 IP 访问时为空
+	Got  []string // 参与匹配的证书公钥指纹
+}
+
+func (e *PinError) Error() string {
+	host := ""
+	if e.Host != "" {
+		host = " for " + e.Host
+	}
+	return fmt.Sprintf("certificate pin mismatch%s (got %s)", host, strings.Join(e.Got, ", "))
+}
+
+// PublicKeyPin 计算证书公钥 (SubjectPublicKeyInfo) 的 SHA-256 指纹，格式 sha256/<base64>
+func PublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// normalizePin 兼容 sha256/xxx、sha256//xxx (curl) 与不带前缀的写法
+func normalizePin(pin string) (string, error) {
+	pin = strings.TrimSpace(pin)
+	pin = strings.TrimPrefix(pin, "sha256/")
+	pin = strings.TrimPrefix(pin, "/")
+	raw, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(raw) != sha256.Size {
+		return "", fmt.Errorf("invalid certificate pin %q: want sha256/<base64 of 32 bytes>", pin)
+	}
+	return "sha256/" + pin, nil
+}
+
+// tlsConfig 按配置创建 TLS 配置：默认校验证书且至少 TLS 1.2
+// CAFile 中的证书追加到系统根证书；配置了 Pins 时校验通过的证书链中至少一个公钥需匹配，
+// 不校验证书时服务器发来的链不可信，只匹配叶子证书
+func (e Egress) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: e.Insecure,
+	}
+
+	if e.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(e.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA file failed: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", e.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if len(e.Pins) > 0 {
+		pins := make(map[string]bool, len(e.Pins))
+		for _, pin := range e.Pins {
+			normalized, err := normalizePin(pin)
+			if err != nil {
+				return nil, err
+			}
+			pins[normalized] = true
+		}
+		insecure := e.Insecure
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			// 握手中附带的证书可以是任意的，只有校验通过的链才能证明公钥属于该站点
+			var certs []*x509.Certificate
+			if insecure {
+				certs = cs.PeerCertificates[:min(1, len(cs.PeerCertificates))]
+			} else {
+				for _, chain := range cs.VerifiedChains {
+					certs = append(certs, chain...)
+				}
+			}
+
+			var got []string
+			for _, cert := range certs {
+				pin := PublicKeyPin(cert)
+				if pins[pin] {
+					return nil
+				}
+				got = append(got, pin)
+			}
+			return &PinError{Host: cs.ServerName, Got: got}
+		}
+	}
+
+	return config, nil
+}
+
+// ============ TLS 诊断 ============
+
+// TLSCheck 一个源的 TLS 握手检查结果
+type TLSCheck struct {
+	Source   string    `json:"source"`
+	Host     string    `json:"host"`
+	OK       bool      `json:"ok"`
+	Version  string    `json:"version,omitempty"`
+	Subject  string    `json:"subject,omitempty"`
+	Issuer   string    `json:"issuer,omitempty"`
+	NotAfter time.Time `json:"not_after,omitempty"`
+	Pins     []string  `json:"pins,omitempty"` // 服务器证书链的公钥指纹，可用于 <ID>_TLS_PINS
+	Error    string    `json:"error,omitempty"`
+	Hint     string    `json:"hint,omitempty"`
+}
+
+// tlsChecker 支持 TLS 检查的源
+type tlsChecker interface {
+	CheckTLS(ctx context.Context) TLSCheck
+}
+
+// CheckTLS 对所有使用 HTTPS 的源做一次 TLS 握手检查
+func CheckTLS(ctx context.Context, registry *core.Registry) []TLSCheck {
+	var checks []TLSCheck
+	for _, s := range registry.List() {
+		if checker, ok := s.(tlsChecker); ok {
+			if check := checker.CheckTLS(ctx); check.Host != "" {
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
+
+// CheckTLS 使用源的客户端 (相同的代理与 TLS 配置) 请求站点首页，记录握手结果
+// 握手失败时再以不校验证书的方式直连一次，取回服务器实际返回的证书用于诊断
+func (b *BaseSource) CheckTLS(ctx context.Context) TLSCheck {
+	u, err := url.Parse(b.endpoint.BaseURL)
+	if err != nil || u.Scheme != "https" {
+		return TLSCheck{Source: b.id}
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+	check := TLSCheck{Source: b.id, Host: host}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", b.endpoint.BaseURL+"/", nil)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	resp, err := b.client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		check.OK = true
+		if resp.TLS != nil {
+			describeConnection(&check, *resp.TLS)
+		}
+		return check
+	}
+
+	check.Error = err.Error()
+	check.Hint = tlsHint(b.id, err)
+	if check.Hint == "" {
+		// 非 TLS 问题 (DNS、超时等)，不再探测证书
+		return check
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		Config:    &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return check
+	}
+	defer conn.Close()
+	describeConnection(&check, conn.(*tls.Conn).ConnectionState())
+	return check
+}
+
+func describeConnection(check *TLSCheck, cs tls.ConnectionState) {
+	check.Version = tls.VersionName(cs.Version)
+	if len(cs.PeerCertificates) == 0 {
+		return
+	}
+	leaf := cs.PeerCertificates[0]
+	check.Subject = leaf.Subject.String()
+	check.Issuer = leaf.Issuer.String()
+	check.NotAfter = leaf.NotAfter
+	for _, cert := range cs.PeerCertificates {
+		check.Pins = append(check.Pins, PublicKeyPin(cert))
+	}
+}
+
+// tlsHint 按握手错误类型给出修复建议，非 TLS 错误返回空串
+func tlsHint(id string, err error) string {
+	prefix := strings.ToUpper(id)
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalid          x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		pinErr           *PinError
+		recordErr        tls.RecordHeaderError
+		alertErr         tls.AlertError
+	)
+	switch {
+	case errors.As(err, &pinErr):
+		return fmt.Sprintf("证书公钥与 %s_TLS_PINS 不匹配，站点可能更换了证书，请核对后更新指纹", prefix)
+	case errors.As(err, &unknownAuthority):
+		return fmt.Sprintf("证书不是受信任的 CA 签发的，可通过 %s_CA_FILE 或 MEME_CA_FILE 加入该 CA，或用 %s_TLS_PINS 固定公钥", prefix, prefix)
+	case errors.As(err, &invalid):
+		if invalid.Reason == x509.Expired {
+			return fmt.Sprintf("证书已过期或系统时间不正确；确认站点可信后可设置 %s_TLS_INSECURE=1 临时跳过校验", prefix)
+		}
+		return fmt.Sprintf("证书无效 (%s)；确认站点可信后可设置 %s_TLS_INSECURE=1", invalid.Detail, prefix)
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("证书与域名不匹配，请检查 %s_BASE_URL 是否正确", prefix)
+	case errors.As(err, &recordErr):
+		return "对端不是 TLS 服务，请检查地址的 scheme 与端口"
+	case errors.As(err, &alertErr), strings.Contains(err.Error(), "tls:"):
+		return "TLS 握手被拒绝，站点可能只支持 TLS 1.2 以下的版本或中间设备拦截了连接"
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shadow/meme/internal/core"
+)
+
+// selfSignedCert 生成一张与测试服务无关的自签名证书
+func selfSignedCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "unrelated"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestSourceTLS(t *testing.T) {
+	srv := newUpstreamServer(t, nil, true)
+	cert := srv.Certificate()
+
+	// 在握手发送的证书链末尾附加一张无关证书，它不在校验通过的链上
+	extra := selfSignedCert(t)
+	leaf := srv.TLS.Certificates[0]
+	padded := httptest.NewUnstartedServer(mockUpstream())
+	padded.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Certificate[0], extra.Raw},
+		PrivateKey:  leaf.PrivateKey,
+	}}}
+	padded.StartTLS()
+	t.Cleanup(padded.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wrongPin := "sha256/" + strings.Repeat("A", 43) + "="
+
+	tests := []struct {
+		name    string
+		server  *httptest.Server // 为空时使用 srv
+		egress  Egress
+		wantErr string
+	}{
+		{name: "default verifies", egress: Egress{}, wantErr: "certificate"},
+		{name: "ca file", egress: Egress{CAFile: caFile}},
+		{name: "insecure", egress: Egress{Insecure: true}},
+		{name: "pin", egress: Egress{CAFile: caFile, Pins: []string{PublicKeyPin(cert)}}},
+		{name: "curl style pin without ca", egress: Egress{Insecure: true, Pins: []string{"sha256//" + strings.TrimPrefix(PublicKeyPin(cert), "sha256/")}}},
+		{name: "pin mismatch", egress: Egress{Insecure: true, Pins: []string{wrongPin}}, wantErr: "pin mismatch"},
+		{name: "leaf pin with appended cert", server: padded, egress: Egress{CAFile: caFile, Pins: []string{PublicKeyPin(cert)}}},
+		{name: "appended cert pin", server: padded, egress: Egress{CAFile: caFile, Pins: []string{PublicKeyPin(extra)}}, wantErr: "pin mismatch"},
+		{name: "appended cert pin without ca", server: padded, egress: Egress{Insecure: true, Pins: []string{PublicKeyPin(extra)}}, wantErr: "pin mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := srv
+			if tt.server != nil {
+				server = tt.server
+			}
+			src := NewPdan()
+			src.SetEndpoint(Endpoint{BaseURL: server.URL})
+			if err := src.SetEgress(tt.egress); err != nil {
+				t.Fatalf("SetEgress: %v", err)
+			}
+
+			memes, err := src.Search(context.Background(), "猫", core.SearchOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(memes) == 0 {
+				t.Fatalf("search: %d memes, err %v", len(memes), err)
+			}
+		})
+	}
+}
+
+func TestCheckTLS(t *testing.T) {
+	srv := newUpstreamServer(t, nil, true)
+
+	src := NewPdan()
+	src.SetEndpoint(Endpoint{BaseURL: srv.URL})
+
+	check := src.CheckTLS(context.Background())
+	if check.OK || check.Hint == "" || !strings.Contains(check.Hint, "PDAN_CA_FILE") {
+		t.Errorf("untrusted check = %+v, want failure with CA hint", check)
+	}
+	if len(check.Pins) == 0 || check.Pins[0] != PublicKeyPin(srv.Certificate()) || check.Subject == "" {
+		t.Errorf("untrusted check did not report served certificate: %+v", check)
+	}
+
+	src.SetEgress(Egress{Insecure: true})
+	if check := src.CheckTLS(context.Background()); !check.OK || check.Version == "" {
+		t.Errorf("insecure check = %+v, want ok", check)
+	}
+
+	var pinErr *PinError
+	if err := (Egress{Pins: []string{"bad"}}).validate(); err == nil || errors.As(err, &pinErr) {
+		t.Errorf("validate invalid pin: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/mockupstream"
+	"github.com/shadow/meme/internal/secrets"
+	"github.com/shadow/meme/internal/sources"
+	"github.com/shadow/meme/internal/tools"
+)
+
+// newE2EClient 启动模拟上游并返回连接到完整 MCP Server 的进程内客户端
+func newE2EClient(t *testing.T, opts mockupstream.Options) *client.Client {
+	t.Helper()
+
+	upstream := httptest.NewServer(mockupstream.New(opts))
+	t.Cleanup(upstream.Close)
+	t.Setenv("IMAGE_PROXY_URL", "")
+
+	// 模拟服务按路径区分站点，所有源指向同一地址
+	endpoints := make(map[string]sources.Endpoint)
+	for _, id := range []string{"sougou", "doutub", "douyin", "qudoutu", "doutula", "pdan"} {
+		endpoints[id] = sources.Endpoint{BaseURL: upstream.URL}
+	}
+	registry := core.NewRegistry()
+	sources.RegisterAllSources(registry, &sources.Config{
+		DouyinCookies: []secrets.Secret{secrets.New("sessionid=e2e")},
+		Endpoints:     endpoints,
+	})
+
+	// 图片地址指向各 CDN 的域名，改发到模拟服务
+	target, _ := url.Parse(upstream.URL)
+	s := tools.NewServerWithImageTransport(registry, core.NewResultCache(10, 100), rewriteTransport{target: target})
+	c, err := client.NewInProcessClient(s)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("start client: %v", err)
+	}
+	if _, err := c.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return c
+}
+
+// rewriteTransport 将所有请求改发到 target，保留路径与查询参数
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = t.target.Scheme
+	out.URL.Host = t.target.Host
+	out.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+func callTool(t *testing.T, c *client.Client, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := c.CallTool(ctx, req)
+	if err != nil {
+		t.Fatalf("call %s: %v", name, err)
+	}
+	if result.IsError {
+		t.Fatalf("call %s returned error: %+v", name, result.Content)
+	}
+	return result
+}
+
+func decodeSearchResult(t *testing.T, result *mcp.CallToolResult) core.SearchResult {
+	t.Helper()
+
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("first content is %T, want text", result.Content[0])
+	}
+	var search core.SearchResult
+	if err := json.Unmarshal([]byte(text.Text), &search); err != nil {
+		t.Fatalf("decode search result: %v", err)
+	}
+	return search
+}
+
+func TestE2ESearchMeme(t *testing.T) {
+	c := newE2EClient(t, mockupstream.Options{
+		Total:  15,
+		Faults: map[string]mockupstream.Fault{mockupstream.SitePdan: {Kind: mockupstream.FaultStatus, Status: 503}},
+	})
+
+	result := callTool(t, c, "search_meme", map[string]any{"keyword": "猫", "limit": 3})
+	search := decodeSearchResult(t, result)
+
+	for _, id := range []string{"douyin", "sougou", "doutula"} {
+		if !contains(search.Sources, id) {
+			t.Errorf("sources = %v, want %s", search.Sources, id)
+		}
+	}
+	if !strings.Contains(search.Errors["pdan"], "503") {
+		t.Errorf("errors = %v, want pdan 503", search.Errors)
+	}
+	if len(search.Memes) == 0 {
+		t.Fatal("no memes returned")
+	}
+	for _, m := range search.Memes {
+		if m.ID == "" || m.Thumbnail == "" || !strings.Contains(m.Title, "猫") {
+			t.Errorf("incomplete meme: %+v", m)
+		}
+	}
+}
+
+func TestE2ESearchMemeWithImages(t *testing.T) {
+	c := newE2EClient(t, mockupstream.Options{Total: 5})
+
+	result := callTool(t, c, "search_meme", map[string]any{
+		"keyword":     "开心",
+		"sources":     []string{"sougou"},
+		"with_images": true,
+		"image_count": 2,
+		"max_size":    32,
+	})
+
+	var images int
+	for _, content := range result.Content[1:] {
+		img, ok := content.(mcp.ImageContent)
+		if !ok || !strings.HasPrefix(img.MIMEType, "image/") || img.Data == "" {
+			t.Errorf("unexpected content: %#v", content)
+			continue
+		}
+		images++
+	}
+	if images != 2 {
+		t.Errorf("got %d images, want 2", images)
+	}
+}
+
+func TestE2EDouyinLoggedOut(t *testing.T) {
+	c := newE2EClient(t, mockupstream.Options{
+		Faults: map[string]mockupstream.Fault{mockupstream.SiteDouyin: {Kind: mockupstream.FaultLogout}},
+	})
+
+	search := decodeSearchResult(t, callTool(t, c, "search_meme", map[string]any{"keyword": "猫", "sources": []string{"douyin"}}))
+
+	if !strings.Contains(search.Errors["douyin"], "auth failed") {
+		t.Errorf("errors = %v, want douyin auth failure", search.Errors)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package tools
+
+import (
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shadow/meme/internal/core"
+)
+
+// NewServerWithImageTransport 测试中创建图片请求经由 rt 发送的 MCP Server (如指向模拟服务)
+func NewServerWithImageTransport(registry *core.Registry, cache *core.ResultCache, rt http.RoundTripper) *server.MCPServer {
+	return newServer(registry, cache, newImageFetcher(rt))
+}
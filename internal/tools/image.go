@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/browser"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
+)
+
+const (
+	// maxImageBytes 单张图片允许下载的最大字节数
+	maxImageBytes = 5 << 20
+	// maxImageCount search_meme 单次最多内联返回的图片数量
+	maxImageCount = 10
+	// maxDecodePixels 缩放时允许解码的最大像素数，防止声明超大尺寸的小文件耗尽内存
+	maxDecodePixels = 4096 * 4096
+)
+
+// ImageFetcher 服务端拉取图片，传给需要下载图片的 Tool 与资源
+type ImageFetcher struct {
+	// client 拉取图片，连接时拒绝回环、链路本地与内网地址
+	client *http.Client
+	// proxyClient 访问 IMAGE_PROXY_URL 配置的图片代理，代理通常部署在内网，不做地址检查
+	proxyClient *http.Client
+}
+
+// NewImageFetcher 创建图片下载器，连接时拒绝内网地址 (图片代理除外)
+func NewImageFetcher() *ImageFetcher {
+	return newImageFetcher(nil)
+}
+
+// newImageFetcher rt 非空时所有请求都经由 rt 发送且不再检查内网地址，仅供测试使用
+func newImageFetcher(rt http.RoundTripper) *ImageFetcher {
+	if rt != nil {
+		client := newImageClient(rt)
+		return &ImageFetcher{client: client, proxyClient: client}
+	}
+	return &ImageFetcher{
+		client:      newImageClient(guardedTransport()),
+		proxyClient: newImageClient(http.DefaultTransport),
+	}
+}
+
+func newImageClient(base http.RoundTripper) *http.Client {
+	return &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: tracing.NewTransport(utils.NewLoggingTransport(base)),
+	}
+}
+
+// fetchedImage 服务端拉取到的图片
+type fetchedImage struct {
+	Data     []byte
+	MIMEType string
+	Width    int
+	Height   int
+}
+
+// fetchImage 下载图片，maxSize > 0 时将最长边缩放到 maxSize 像素以内
+func (f *ImageFetcher) fetchImage(ctx context.Context, imgURL string, maxSize int) (*fetchedImage, error) {
+	u, err := checkImageURL(imgURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", imgURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	browser.Default().Apply(req.Header, browser.KindImage)
+
+	client := f.client
+	if isImageProxy(u) {
+		client = f.proxyClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read image failed: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image too large: more than %d bytes", maxImageBytes)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("not an image: %s", mimeType)
+	}
+
+	img := &fetchedImage{Data: data, MIMEType: mimeType}
+
+	// 仅对标准库可解码的格式 (gif/png/jpeg) 读取尺寸及缩放，其余原样返回
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return img, nil
+	}
+	img.Width, img.Height = cfg.Width, cfg.Height
+
+	if maxSize <= 0 || (cfg.Width <= maxSize && cfg.Height <= maxSize) {
+		return img, nil
+	}
+	// 解码后按 RGBA 占用内存，先按声明的尺寸拒绝超大图片
+	if int64(cfg.Width)*int64(cfg.Height) > maxDecodePixels {
+		return nil, fmt.Errorf("image too large to resize: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	_, span := tracing.Start(ctx, "resize_image",
+		tracing.Int("image.width", cfg.Width),
+		tracing.Int("image.height", cfg.Height),
+		tracing.Int("image.max_size", maxSize),
+	)
+	defer span.End()
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return img, nil
+	}
+	dst := downscale(src, maxSize)
+
+	// 动图缩放后只保留首帧，统一编码为 PNG；JPEG 保持 JPEG 以控制体积
+	var buf bytes.Buffer
+	if mimeType == "image/jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+		mimeType = "image/png"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode image failed: %w", err)
+	}
+
+	bounds := dst.Bounds()
+	return &fetchedImage{
+		Data:     buf.Bytes(),
+		MIMEType: mimeType,
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+	}, nil
+}
+
+// ============ 地址检查 ============
+
+// errBlockedAddress 图片地址解析到了回环、链路本地或内网地址
+var errBlockedAddress = errors.New("image host resolves to a private or local address")
+
+// checkImageURL 图片地址必须是带主机名的 http(s) 绝对地址
+func checkImageURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid image url: %q is not an absolute http(s) URL", raw)
+	}
+	return u, nil
+}
+
+// isImageProxy 是否为 IMAGE_PROXY_URL 配置的图片代理地址
+func isImageProxy(u *url.URL) bool {
+	proxy, err := url.Parse(os.Getenv("IMAGE_PROXY_URL"))
+	return err == nil && proxy.Host != "" && strings.EqualFold(proxy.Host, u.Host)
+}
+
+// guardedTransport 在建立连接时 (DNS 解析之后) 检查目标地址，域名解析到内网地址或重定向到内网时同样拒绝
+// 不使用环境变量中的代理，否则只能检查到代理的地址
+func guardedTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || isBlockedAddr(addr.Addr()) {
+				return fmt.Errorf("dial %s: %w", address, errBlockedAddress)
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// carrierNAT 运营商级 NAT 地址段 (RFC 6598)，不属于公网
+var carrierNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// isBlockedAddr 回环、链路本地、内网、未指定与组播地址不允许访问
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() || carrierNAT.Contains(addr)
+}
+
+// fetchMemeImage 依次尝试表情包的主地址与备用地址，返回第一个成功的图片
+func (f *ImageFetcher) fetchMemeImage(ctx context.Context, meme core.Meme, maxSize int) (*fetchedImage, error) {
+	var lastErr error
+	for i, imgURL := range meme.URLs() {
+		img, err := f.fetchImage(ctx, imgURL, maxSize)
+		if err == nil {
+			return img, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		logger.DebugContext(ctx, "image url failed, trying next", "url", imgURL, "attempt", i+1, "error", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// downscale 使用区域平均将图片最长边缩放到 maxSize
+func downscale(src image.Image, maxSize int) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+
+	dw, dh := maxSize, maxSize
+	if sw >= sh {
+		dh = max(1, sh*maxSize/sw)
+	} else {
+		dw = max(1, sw*maxSize/sh)
+	}
+
+	// 先转为 RGBA，便于直接读取像素
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, sb.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := rgba.PixOffset(sx, sy)
+					r += uint32(rgba.Pix[i])
+					g += uint32(rgba.Pix[i+1])
+					b += uint32(rgba.Pix[i+2])
+					a += uint32(rgba.Pix[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// fetchMemeImages 并发拉取前 count 个表情包图片，失败的条目以 nil 占位
+func (f *ImageFetcher) fetchMemeImages(ctx context.Context, memes []core.Meme, count, maxSize int) ([]*fetchedImage, map[string]string) {
+	if count > len(memes) {
+		count = len(memes)
+	}
+
+	ctx, span := tracing.Start(ctx, "fetch_images", tracing.Int("image.count", count))
+	defer span.End()
+
+	images := make([]*fetchedImage, count)
+	errs := make(map[string]string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			img, err := f.fetchMemeImage(ctx, memes[i], maxSize)
+			if err != nil {
+				mu.Lock()
+				errs[memes[i].URL] = err.Error()
+				mu.Unlock()
+				return
+			}
+			images[i] = img
+		}(i)
+	}
+	wg.Wait()
+
+	return images, errs
+}
+
+// newImageContent 将图片转换为 MCP ImageContent
+func newImageContent(img *fetchedImage) mcp.ImageContent {
+	return mcp.NewImageContent(base64.StdEncoding.EncodeToString(img.Data), img.MIMEType)
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestCheckImageURL(t *testing.T) {
+	for _, raw := range []string{
+		"file:///etc/passwd.png",
+		"gopher://example.com/a.png",
+		"/relative/a.png",
+		"https:///a.png",
+	} {
+		if _, err := checkImageURL(raw); err == nil {
+			t.Errorf("checkImageURL(%q) = nil, want error", raw)
+		}
+	}
+	if _, err := checkImageURL("https://img.example.com/a.png"); err != nil {
+		t.Errorf("checkImageURL: %v", err)
+	}
+}
+
+func TestIsBlockedAddr(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":        true,
+		"169.254.169.254":  true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"192.168.1.1":      true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"::1":              true,
+		"fe80::1":          true,
+		"fd00::1":          true,
+		"::ffff:127.0.0.1": true,
+		"8.8.8.8":          false,
+		"2001:4860::8888":  false,
+	}
+	for raw, want := range tests {
+		if got := isBlockedAddr(netip.MustParseAddr(raw)); got != want {
+			t.Errorf("isBlockedAddr(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestFetchImageBlocksPrivateAddress(t *testing.T) {
+	t.Setenv("IMAGE_PROXY_URL", "")
+	images := NewImageFetcher()
+
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write(pngBytes(t, 1, 1))
+	}))
+	t.Cleanup(srv.Close)
+
+	// 元数据地址与本机地址在建立连接前被拒绝
+	for _, u := range []string{"http://169.254.169.254/latest?x.png", srv.URL + "/a.png"} {
+		_, err := images.fetchImage(context.Background(), u, 0)
+		if !errors.Is(err, errBlockedAddress) {
+			t.Errorf("fetchImage(%s) error = %v, want errBlockedAddress", u, err)
+		}
+	}
+
+	// 跳转到内网同样被拒绝
+	redirect := httptest.NewServer(http.RedirectHandler(srv.URL+"/a.png", http.StatusFound))
+	t.Cleanup(redirect.Close)
+	if _, err := images.fetchImage(context.Background(), redirect.URL+"/b.png", 0); !errors.Is(err, errBlockedAddress) {
+		t.Errorf("redirect error = %v, want errBlockedAddress", err)
+	}
+	if hits != 0 {
+		t.Errorf("private server received %d requests", hits)
+	}
+
+	// 配置的图片代理允许位于内网
+	t.Setenv("IMAGE_PROXY_URL", srv.URL+"/proxy?url={URL}")
+	if _, err := images.fetchImage(context.Background(), srv.URL+"/proxy?url=a.png", 0); err != nil {
+		t.Errorf("fetch through image proxy: %v", err)
+	}
+}
+
+func TestFetchImagePixelBudget(t *testing.T) {
+	t.Setenv("IMAGE_PROXY_URL", "")
+
+	// 只有文件头的 PNG，声明 30000x30000，完整解码需要约 3.6 GB
+	huge := pngHeader(30000, 30000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "huge.png") {
+			w.Write(huge)
+			return
+		}
+		w.Write(pngBytes(t, 64, 32))
+	}))
+	t.Cleanup(srv.Close)
+	images := newImageFetcher(http.DefaultTransport)
+
+	if _, err := images.fetchImage(context.Background(), srv.URL+"/huge.png", 128); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("error = %v, want image too large", err)
+	}
+	// 不缩放时不解码，原样返回
+	if img, err := images.fetchImage(context.Background(), srv.URL+"/huge.png", 0); err != nil || img.Width != 30000 {
+		t.Errorf("fetch without resize: %+v, %v", img, err)
+	}
+
+	img, err := images.fetchImage(context.Background(), srv.URL+"/small.png", 16)
+	if err != nil {
+		t.Fatalf("fetch small image: %v", err)
+	}
+	if img.Width != 16 || img.Height != 8 {
+		t.Errorf("resized to %dx%d, want 16x8", img.Width, img.Height)
+	}
+}
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader 构造只包含签名与 IHDR 的 PNG，足以让 DecodeConfig 读出尺寸
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 6 // 8 位 RGBA
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
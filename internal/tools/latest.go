@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/core"
+)
+
+// LatestMemesArgs latest_memes 工具的参数
+type LatestMemesArgs struct {
+	Sources []string `json:"sources,omitempty"` // 可选，指定浏览的源
+	Page    int      `json:"page,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+}
+
+// NewLatestMemesTool 创建 latest_memes MCP Tool
+func NewLatestMemesTool() mcp.Tool {
+	return mcp.NewTool(
+		"latest_memes",
+		mcp.WithDescription("浏览最新表情包，无需关键词。聚合所有支持浏览最新列表的源，返回去重后的结果列表。"),
+		mcp.WithArray("sources",
+			mcp.Description("可选，指定浏览的源ID列表。不指定则浏览所有支持的源"),
+		),
+		mcp.WithNumber("page",
+			mcp.Description("页码，默认为 1"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("每个源返回的最大数量，默认为 20"),
+		),
+	)
+}
+
+// HandleLatestMemes 处理 latest_memes 请求
+func HandleLatestMemes(registry *core.Registry) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logger.DebugContext(ctx, "latest_memes request received", "arguments", request.Params.Arguments)
+
+		var args LatestMemesArgs
+		argsBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+		if err := json.Unmarshal(argsBytes, &args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+
+		opts := core.DefaultSearchOptions()
+		if args.Page > 0 {
+			opts.Page = args.Page
+		}
+		if args.Limit > 0 {
+			opts.Limit = args.Limit
+		}
+
+		result := registry.Browse(ctx, core.BrowseLatest, args.Sources, opts)
+
+		logger.InfoContext(ctx, "latest_memes completed", "results", len(result.Memes), "duration_ms", result.DurationMs)
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("结果序列化失败: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(buf.String()), nil
+	}
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shadow/meme/internal/metrics"
+	"github.com/shadow/meme/internal/tracing"
+	"github.com/shadow/meme/internal/utils"
+)
+
+// LoggingMiddleware 为每次工具调用分配请求ID，并记录调用结果与耗时
+func LoggingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = utils.EnsureRequestID(ctx)
+		start := time.Now()
+
+		result, err := next(ctx, request)
+
+		duration := time.Since(start).Milliseconds()
+		switch {
+		case err != nil:
+			logger.ErrorContext(ctx, "tool call failed", "tool", request.Params.Name, "duration_ms", duration, "error", err)
+		case result != nil && result.IsError:
+			logger.WarnContext(ctx, "tool call returned error", "tool", request.Params.Name, "duration_ms", duration)
+		default:
+			logger.InfoContext(ctx, "tool call completed", "tool", request.Params.Name, "duration_ms", duration)
+		}
+		return result, err
+	}
+}
+
+// TracingMiddleware 为每次工具调用创建根 Span，工具内的搜索与 HTTP 请求均挂在其下
+func TracingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := tracing.StartKind(ctx, "tool/"+request.Params.Name, tracing.KindServer,
+			tracing.String("mcp.tool", request.Params.Name),
+		)
+		defer span.End()
+
+		result, err := next(ctx, request)
+		if err != nil {
+			span.RecordError(err)
+		} else if result != nil && result.IsError {
+			span.SetStatus(tracing.StatusError, "tool returned error")
+		}
+		return result, err
+	}
+}
+
+// MetricsMiddleware 记录每次工具调用的次数、结果与耗时
+func MetricsMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		outcome := "success"
+		if err != nil || (result != nil && result.IsError) {
+			outcome = "error"
+		}
+		metrics.ToolCalls.Inc(request.Params.Name, outcome)
+		metrics.ToolDuration.Observe(time.Since(start).Seconds(), request.Params.Name)
+
+		return result, err
+	}
+}
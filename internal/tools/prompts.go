@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/core"
+)
+
+// toolNames 返回提示词中引用的工具名，与工具定义保持一致
+func toolNames(registry *core.Registry) (search, image, list string) {
+	return NewSearchMemeTool(registry).Name, NewGetMemeImageTool().Name, NewListSourcesTool().Name
+}
+
+// sourceIDs 返回当前可用的源 ID 列表 (排序后)
+func sourceIDs(registry *core.Registry) string {
+	ids := registry.ListIDs()
+	sort.Strings(ids)
+	return strings.Join(ids, ", ")
+}
+
+// userPrompt 构造只包含一条用户消息的提示词结果
+func userPrompt(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+// NewReplyWithMemePrompt 创建 reply_with_meme MCP Prompt
+func NewReplyWithMemePrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"reply_with_meme",
+		mcp.WithPromptDescription("为一条消息挑选一张合适的表情包作为回复"),
+		mcp.WithArgument("message",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("需要回复的消息原文"),
+		),
+		mcp.WithArgument("mood",
+			mcp.ArgumentDescription("可选，希望表达的情绪，如：开心、无语、委屈"),
+		),
+	)
+}
+
+// HandleReplyWithMemePrompt 处理 reply_with_meme 请求
+func HandleReplyWithMemePrompt(registry *core.Registry) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		logger.DebugContext(ctx, "prompt requested", "prompt", "reply_with_meme", "arguments", request.Params.Arguments)
+
+		message := request.Params.Arguments["message"]
+		if message == "" {
+			return nil, fmt.Errorf("message argument is required")
+		}
+		mood := request.Params.Arguments["mood"]
+		if mood == "" {
+			mood = "根据消息内容自行判断"
+		}
+
+		search, image, _ := toolNames(registry)
+		text := fmt.Sprintf(`请用一张表情包回复下面这条消息。
+
+消息: %s
+希望表达的情绪: %s
+
+步骤:
+1. 从消息和情绪中提炼 1-3 个简短的中文关键词 (2-4 个字为宜，如"加班""无语""开心")，不要直接用整句话搜索。
+2. 依次用这些关键词调用 %s，limit 设为 5 左右即可；第一个关键词结果足够时不必继续。
+3. 根据标题与情绪的契合度挑选唯一一张最合适的表情包，避免与消息语义相反的结果。
+4. 如需确认图片内容，可调用 %s 查看候选图片。
+5. 最终只回复所选表情包的 url，并用一句话说明选择理由。
+
+可用源: %s`, message, mood, search, image, sourceIDs(registry))
+
+		return userPrompt("为消息挑选表情包回复", text), nil
+	}
+}
+
+// NewMemeForEmotionPrompt 创建 meme_for_emotion MCP Prompt
+func NewMemeForEmotionPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"meme_for_emotion",
+		mcp.WithPromptDescription("根据一种情绪查找并推荐表情包"),
+		mcp.WithArgument("emotion",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("情绪，如：开心、难过、生气、尴尬"),
+		),
+		mcp.WithArgument("count",
+			mcp.ArgumentDescription("可选，推荐的数量，默认为 3"),
+		),
+	)
+}
+
+// HandleMemeForEmotionPrompt 处理 meme_for_emotion 请求
+func HandleMemeForEmotionPrompt(registry *core.Registry) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		logger.DebugContext(ctx, "prompt requested", "prompt", "meme_for_emotion", "arguments", request.Params.Arguments)
+
+		emotion := request.Params.Arguments["emotion"]
+		if emotion == "" {
+			return nil, fmt.Errorf("emotion argument is required")
+		}
+		count := request.Params.Arguments["count"]
+		if count == "" {
+			count = "3"
+		}
+
+		search, _, _ := toolNames(registry)
+		text := fmt.Sprintf(`请推荐 %s 张能表达"%s"情绪的表情包。
+
+步骤:
+1. 先直接用"%s"作为关键词调用 %s。
+2. 如果结果不足，再换 1-2 个近义词 (如同义的网络用语) 继续搜索。
+3. 去掉标题与情绪明显不符的结果，尽量覆盖不同的源和风格。
+4. 以列表形式返回，每项包含标题、来源和 url。
+
+可用源: %s`, count, emotion, emotion, search, sourceIDs(registry))
+
+		return userPrompt("按情绪推荐表情包", text), nil
+	}
+}
+
+// NewCompareSourcesPrompt 创建 compare_sources MCP Prompt
+func NewCompareSourcesPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"compare_sources",
+		mcp.WithPromptDescription("用同一关键词对比各个数据源的搜索结果"),
+		mcp.WithArgument("keyword",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("用于对比的搜索关键词"),
+		),
+	)
+}
+
+// HandleCompareSourcesPrompt 处理 compare_sources 请求
+func HandleCompareSourcesPrompt(registry *core.Registry) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		logger.DebugContext(ctx, "prompt requested", "prompt", "compare_sources", "arguments", request.Params.Arguments)
+
+		keyword := request.Params.Arguments["keyword"]
+		if keyword == "" {
+			return nil, fmt.Errorf("keyword argument is required")
+		}
+
+		search, _, list := toolNames(registry)
+		text := fmt.Sprintf(`请对比各个表情包数据源对关键词"%s"的搜索效果。
+
+步骤:
+1. 调用 %s 查看当前可用的源及其说明。
+2. 对每个源分别调用 %s，sources 参数只填该源 ID，limit 设为 5。
+3. 汇总为表格: 源 ID、结果数量、是否失败及原因、结果与关键词的相关性、图片质量。
+4. 最后给出该关键词下最推荐使用的源。
+
+可用源: %s`, keyword, list, search, sourceIDs(registry))
+
+		return userPrompt("对比各数据源的搜索结果", text), nil
+	}
+}
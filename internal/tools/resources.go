@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/sources"
+)
+
+// 资源 URI
+const (
+	sourcesURI        = "meme://sources"
+	sourceURITemplate = "meme://source/{id}"
+	searchesURI       = "meme://searches"
+	searchURITemplate = "meme://search/{keyword}"
+	imageURITemplate  = "meme://image/{hash}"
+)
+
+// templateArg 读取 URI 模板匹配出的变量
+func templateArg(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// jsonContents 将数据序列化为 JSON 资源内容
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal resource failed: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// NewSourcesResource 创建 meme://sources 资源
+func NewSourcesResource() mcp.Resource {
+	return mcp.NewResource(
+		sourcesURI,
+		"表情包数据源",
+		mcp.WithResourceDescription("当前已加载的所有表情包数据源"),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+// HandleSourcesResource 处理 meme://sources 读取请求
+func HandleSourcesResource(registry *core.Registry) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logger.DebugContext(ctx, "resource read", "uri", request.Params.URI)
+		return jsonContents(request.Params.URI, sources.GetAllSourceInfo(registry))
+	}
+}
+
+// NewSourceResourceTemplate 创建 meme://source/{id} 资源模板
+func NewSourceResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		sourceURITemplate,
+		"表情包数据源详情",
+		mcp.WithTemplateDescription("指定 ID 的表情包数据源信息"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+// HandleSourceResource 处理 meme://source/{id} 读取请求
+func HandleSourceResource(registry *core.Registry) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logger.DebugContext(ctx, "resource read", "uri", request.Params.URI)
+
+		id := templateArg(request, "id")
+		for _, info := range sources.GetAllSourceInfo(registry) {
+			if info.ID == id {
+				return jsonContents(request.Params.URI, info)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", core.ErrSourceNotFound, id)
+	}
+}
+
+// NewSearchesResource 创建 meme://searches 资源
+func NewSearchesResource() mcp.Resource {
+	return mcp.NewResource(
+		searchesURI,
+		"最近的搜索",
+		mcp.WithResourceDescription("最近缓存的搜索关键词，可通过 meme://search/{keyword} 读取完整结果"),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+// HandleSearchesResource 处理 meme://searches 读取请求
+func HandleSearchesResource(cache *core.ResultCache) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logger.DebugContext(ctx, "resource read", "uri", request.Params.URI)
+
+		type searchSummary struct {
+			Keyword  string `json:"keyword"`
+			URI      string `json:"uri"`
+			Total    int    `json:"total"`
+			CachedAt string `json:"cached_at"`
+		}
+
+		searches := cache.ListSearches()
+		summaries := make([]searchSummary, 0, len(searches))
+		for _, s := range searches {
+			summaries = append(summaries, searchSummary{
+				Keyword:  s.Keyword,
+				URI:      "meme://search/" + url.PathEscape(s.Keyword),
+				Total:    s.Result.Total,
+				CachedAt: s.CachedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+		return jsonContents(request.Params.URI, summaries)
+	}
+}
+
+// NewSearchResourceTemplate 创建 meme://search/{keyword} 资源模板
+func NewSearchResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		searchURITemplate,
+		"缓存的搜索结果",
+		mcp.WithTemplateDescription("读取最近一次 search_meme 对该关键词的缓存结果 (所有源的第一页)，不会重新搜索"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+// HandleSearchResource 处理 meme://search/{keyword} 读取请求
+func HandleSearchResource(cache *core.ResultCache) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logger.DebugContext(ctx, "resource read", "uri", request.Params.URI)
+
+		keyword := templateArg(request, "keyword")
+		if unescaped, err := url.PathUnescape(keyword); err == nil {
+			keyword = unescaped
+		}
+		search, ok := cache.GetSearch(keyword)
+		if !ok {
+			return nil, fmt.Errorf("no cached search for keyword: %s", keyword)
+		}
+		return jsonContents(request.Params.URI, search)
+	}
+}
+
+// NewImageResourceTemplate 创建 meme://image/{hash} 资源模板
+func NewImageResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		imageURITemplate,
+		"表情包图片",
+		mcp.WithTemplateDescription("按 search_meme 结果中的 id 读取曾经返回过的表情包图片"),
+	)
+}
+
+// HandleImageResource 处理 meme://image/{hash} 读取请求
+func HandleImageResource(cache *core.ResultCache, images *ImageFetcher) func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logger.DebugContext(ctx, "resource read", "uri", request.Params.URI)
+
+		hash := templateArg(request, "hash")
+		meme, ok := cache.GetMeme(hash)
+		if !ok {
+			return nil, fmt.Errorf("unknown meme id: %s", hash)
+		}
+
+		img, err := images.fetchMemeImage(ctx, meme, 0)
+		if err != nil {
+			return nil, fmt.Errorf("fetch image failed: %w", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.BlobResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: img.MIMEType,
+				Blob:     base64.StdEncoding.EncodeToString(img.Data),
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/shadow/meme/internal/core"
+)
+
+// NewServer 创建注册了全部 Tools、Resources 与 Prompts 的 MCP Server
+func NewServer(registry *core.Registry, cache *core.ResultCache) *server.MCPServer {
+	return newServer(registry, cache, NewImageFetcher())
+}
+
+func newServer(registry *core.Registry, cache *core.ResultCache, images *ImageFetcher) *server.MCPServer {
+	s := server.NewMCPServer(
+		"meme-server",
+		"1.0.0",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+		server.WithToolHandlerMiddleware(TracingMiddleware),
+		server.WithToolHandlerMiddleware(LoggingMiddleware),
+		server.WithToolHandlerMiddleware(MetricsMiddleware),
+	)
+
+	// 注册 Tools
+	s.AddTool(NewSearchMemeTool(registry), HandleSearchMeme(registry, cache, images))
+	s.AddTool(NewListSourcesTool(), HandleListSources(registry))
+	s.AddTool(NewGetMemeImageTool(), HandleGetMemeImage(images))
+	s.AddTool(NewSuggestMemeTool(), HandleSuggestMeme(registry, cache))
+	s.AddTool(NewLatestMemesTool(), HandleLatestMemes(registry))
+
+	// 注册 Resources
+	s.AddResource(NewSourcesResource(), HandleSourcesResource(registry))
+	s.AddResourceTemplate(NewSourceResourceTemplate(), HandleSourceResource(registry))
+	s.AddResource(NewSearchesResource(), HandleSearchesResource(cache))
+	s.AddResourceTemplate(NewSearchResourceTemplate(), HandleSearchResource(cache))
+	s.AddResourceTemplate(NewImageResourceTemplate(), HandleImageResource(cache, images))
+
+	// 注册 Prompts
+	s.AddPrompt(NewReplyWithMemePrompt(), HandleReplyWithMemePrompt(registry))
+	s.AddPrompt(NewMemeForEmotionPrompt(), HandleMemeForEmotionPrompt(registry))
+	s.AddPrompt(NewCompareSourcesPrompt(), HandleCompareSourcesPrompt(registry))
+
+	return s
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/shadow/meme/internal/core"
+	"github.com/shadow/meme/internal/tracing"
+)
+
+// SuggestMemeArgs suggest_meme 工具的参数
+type SuggestMemeArgs struct {
+	Text        string   `json:"text"`
+	Sources     []string `json:"sources,omitempty"`
+	MaxKeywords int      `json:"max_keywords,omitempty"`
+	Limit       int      `json:"limit,omitempty"`
+}
+
+// SuggestResult suggest_meme 的返回结果
+type SuggestResult struct {
+	Keywords   []string          `json:"keywords"` // 实际用于搜索的关键词，按优先级排序
+	Memes      []core.Meme       `json:"memes"`
+	Sources    []string          `json:"sources"`
+	Errors     map[string]string `json:"errors"`
+	Total      int               `json:"total"`
+	DurationMs int64             `json:"duration_ms"`
+	TraceID    string            `json:"trace_id,omitempty"`
+}
+
+// NewSuggestMemeTool 创建 suggest_meme MCP Tool
+func NewSuggestMemeTool() mcp.Tool {
+	return mcp.NewTool(
+		"suggest_meme",
+		mcp.WithDescription("根据一句话推荐表情包。自动从文本中提取情绪/场景关键词，并行搜索后合并排序返回，适合直接传入对话内容。"),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("任意文本，如：当老板说今晚要加班的时候"),
+		),
+		mcp.WithArray("sources",
+			mcp.Description("可选，指定搜索的源ID列表。不指定则搜索所有源"),
+		),
+		mcp.WithNumber("max_keywords",
+			mcp.Description("最多使用的关键词数量，默认为 3"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("返回的最大数量，默认为 20"),
+		),
+	)
+}
+
+// HandleSuggestMeme 处理 suggest_meme 请求
+func HandleSuggestMeme(registry *core.Registry, cache *core.ResultCache) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logger.DebugContext(ctx, "suggest_meme request received", "arguments", request.Params.Arguments)
+
+		var args SuggestMemeArgs
+		argsBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+		if err := json.Unmarshal(argsBytes, &args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+
+		maxKeywords := args.MaxKeywords
+		if maxKeywords <= 0 {
+			maxKeywords = 3
+		}
+		keywords := core.ExtractKeywords(args.Text, maxKeywords)
+		if len(keywords) == 0 {
+			return mcp.NewToolResultError("无法从 text 中提取关键词"), nil
+		}
+
+		limit := args.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+
+		logger.InfoContext(ctx, "suggest_meme extracted keywords", "keywords", keywords)
+
+		result := suggestMemes(ctx, registry, cache, keywords, args.Sources, limit)
+
+		logger.InfoContext(ctx, "suggest_meme completed", "results", result.Total, "duration_ms", result.DurationMs)
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("结果序列化失败: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(buf.String()), nil
+	}
+}
+
+// suggestMemes 并行搜索所有关键词，并按倒数排名融合 (RRF) 合并结果
+// 排名越靠前的关键词权重越高，被多个关键词命中的表情包得分累加
+func suggestMemes(ctx context.Context, registry *core.Registry, cache *core.ResultCache, keywords, sourceIDs []string, limit int) SuggestResult {
+	startTime := time.Now()
+
+	opts := core.DefaultSearchOptions()
+	results := make([]core.SearchResult, len(keywords))
+
+	var wg sync.WaitGroup
+	for i, keyword := range keywords {
+		wg.Add(1)
+		go func(i int, keyword string) {
+			defer wg.Done()
+			results[i] = registry.SearchSources(ctx, keyword, sourceIDs, opts)
+			cache.Put(keyword, sourceIDs, opts, results[i])
+		}(i, keyword)
+	}
+	wg.Wait()
+
+	_, span := tracing.Start(ctx, "rrf_merge", tracing.Int("suggest.keywords", len(keywords)))
+	defer span.End()
+
+	// rrfK 平滑常数，避免单个结果的排名主导总分
+	const rrfK = 60
+
+	scores := make(map[string]float64)
+	memes := make(map[string]core.Meme)
+	var order []string
+
+	succeeded := make(map[string]bool)
+	errors := make(map[string]string)
+
+	for k, result := range results {
+		weight := 1.0 / float64(k+1)
+		for pos, meme := range result.Memes {
+			hash := core.MemeHash(meme)
+			if _, ok := memes[hash]; !ok {
+				memes[hash] = meme
+				order = append(order, hash)
+			}
+			scores[hash] += weight / float64(rrfK+pos+1)
+		}
+		for _, id := range result.Sources {
+			succeeded[id] = true
+		}
+		for id, msg := range result.Errors {
+			errors[id] = msg
+		}
+	}
+
+	// 只要在任一关键词下成功过，就不算失败的源
+	successSources := []string{}
+	for id := range succeeded {
+		successSources = append(successSources, id)
+		delete(errors, id)
+	}
+	sort.Strings(successSources)
+
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+
+	ranked := make([]core.Meme, 0, len(order))
+	for _, hash := range order {
+		ranked = append(ranked, memes[hash])
+	}
+
+	return SuggestResult{
+		Keywords:   keywords,
+		Memes:      ranked,
+		Sources:    successSources,
+		Errors:     errors,
+		Total:      len(ranked),
+		DurationMs: time.Since(startTime).Milliseconds(),
+		TraceID:    span.TraceID.String(),
+	}
+}
@@ -5,19 +5,33 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/shadow/meme/internal/core"
 	"github.com/shadow/meme/internal/sources"
+	"github.com/shadow/meme/internal/utils"
 )
 
+var logger = utils.Logger("tools")
+
 // SearchMemeArgs search_meme 工具的参数
 type SearchMemeArgs struct {
 	Keyword string   `json:"keyword"`
 	Sources []string `json:"sources,omitempty"` // 可选，指定搜索的源
 	Page    int      `json:"page,omitempty"`
 	Limit   int      `json:"limit,omitempty"`
+	// MinResults 收集到该数量的结果后立即返回，不再等待慢源
+	MinResults int `json:"min_results,omitempty"`
+	// DeadlineMs 整体截止时间 (毫秒)，到时返回已收集的结果
+	DeadlineMs int `json:"deadline_ms,omitempty"`
+	// HedgeAfterMs 源超过该时间未返回则发起对冲请求 (毫秒)
+	HedgeAfterMs int `json:"hedge_after_ms,omitempty"`
+	// 内联图片选项
+	WithImages bool `json:"with_images,omitempty"` // 是否在结果中附带图片内容
+	ImageCount int  `json:"image_count,omitempty"` // 附带前 N 张图片，默认 3
+	MaxSize    int  `json:"max_size,omitempty"`    // 图片最长边像素，超过则缩放，0 表示不缩放
 }
 
 // NewSearchMemeTool 创建 search_meme MCP Tool
@@ -38,30 +52,47 @@ func NewSearchMemeTool(registry *core.Registry) mcp.Tool {
 		mcp.WithNumber("limit",
 			mcp.Description("每个源返回的最大数量，默认为 20"),
 		),
+		mcp.WithNumber("min_results",
+			mcp.Description("可选，收集到该数量的去重结果后立即返回，不再等待较慢的源"),
+		),
+		mcp.WithNumber("deadline_ms",
+			mcp.Description("可选，整个搜索的截止时间 (毫秒)，到时返回已收集的结果，未完成的源记入 cancelled"),
+		),
+		mcp.WithNumber("hedge_after_ms",
+			mcp.Description("可选，源超过该时间 (毫秒) 未返回时再发起一次相同请求，取先返回的结果"),
+		),
+		mcp.WithBoolean("with_images",
+			mcp.Description("可选，是否由服务端下载前 N 张图片并以图片内容返回，便于客户端直接展示"),
+		),
+		mcp.WithNumber("image_count",
+			mcp.Description("with_images 为 true 时返回的图片数量，默认为 3，最多 10"),
+		),
+		mcp.WithNumber("max_size",
+			mcp.Description("可选，图片最长边像素，超过则缩放 (仅支持 gif/png/jpg)，默认不缩放"),
+		),
 	)
 }
 
-// HandleSearchMeme 处理 search_meme 请求
-func HandleSearchMeme(registry *core.Registry) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// HandleSearchMeme 处理 search_meme 请求，结果会写入 cache 以便通过资源回查
+func HandleSearchMeme(registry *core.Registry, cache *core.ResultCache, images *ImageFetcher) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// 日志：接收到请求
-		fmt.Fprintf(os.Stderr, "[SearchMeme] Request received. Params: %+v\n", request.Params.Arguments)
+		logger.DebugContext(ctx, "search_meme request received", "arguments", request.Params.Arguments)
 
 		// 解析参数
 		var args SearchMemeArgs
 		argsBytes, err := json.Marshal(request.Params.Arguments)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[SearchMeme] Failed to marshal params: %v\n", err)
+			logger.WarnContext(ctx, "search_meme invalid arguments", "error", err)
 			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
 		}
 		if err := json.Unmarshal(argsBytes, &args); err != nil {
-			fmt.Fprintf(os.Stderr, "[SearchMeme] Failed to unmarshal params: %v\n", err)
+			logger.WarnContext(ctx, "search_meme invalid arguments", "error", err)
 			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
 		}
 
 		// 验证参数
 		if args.Keyword == "" {
-			fmt.Fprintf(os.Stderr, "[SearchMeme] Error: keyword is empty\n")
+			logger.WarnContext(ctx, "search_meme keyword is empty")
 			return mcp.NewToolResultError("keyword 参数不能为空"), nil
 		}
 
@@ -73,18 +104,24 @@ func HandleSearchMeme(registry *core.Registry) func(ctx context.Context, request
 		if args.Limit > 0 {
 			opts.Limit = args.Limit
 		}
+		if args.MinResults > 0 {
+			opts.MinResults = args.MinResults
+		}
+		if args.DeadlineMs > 0 {
+			opts.Deadline = time.Duration(args.DeadlineMs) * time.Millisecond
+		}
+		if args.HedgeAfterMs > 0 {
+			opts.HedgeAfter = time.Duration(args.HedgeAfterMs) * time.Millisecond
+		}
 
-		fmt.Fprintf(os.Stderr, "[SearchMeme] Searching with args: keyword=%s, sources=%v, page=%d, limit=%d\n", args.Keyword, args.Sources, opts.Page, opts.Limit)
+		logger.InfoContext(ctx, "search_meme searching", "keyword", args.Keyword, "sources", args.Sources, "page", opts.Page, "limit", opts.Limit)
 
-		// 执行搜索
-		var result core.SearchResult
-		if len(args.Sources) > 0 {
-			result = registry.SearchSources(ctx, args.Keyword, args.Sources, opts)
-		} else {
-			result = registry.SearchAll(ctx, args.Keyword, opts)
-		}
+		// 执行搜索，每个源完成时发送进度通知
+		result := registry.SearchStream(ctx, args.Keyword, args.Sources, opts, progressReporter(ctx, request))
+
+		logger.InfoContext(ctx, "search_meme completed", "results", len(result.Memes), "duration_ms", result.DurationMs)
 
-		fmt.Fprintf(os.Stderr, "[SearchMeme] Search completed. Found %d items. Duration: %dms\n", len(result.Memes), result.DurationMs)
+		cache.Put(args.Keyword, args.Sources, opts, result)
 
 		// 构造返回结果，禁用 HTML 转义以正确显示 & 符号
 		var buf bytes.Buffer
@@ -92,11 +129,132 @@ func HandleSearchMeme(registry *core.Registry) func(ctx context.Context, request
 		encoder.SetEscapeHTML(false)
 		encoder.SetIndent("", "  ")
 		if err := encoder.Encode(result); err != nil {
-			fmt.Fprintf(os.Stderr, "[SearchMeme] Failed to marshal result: %v\n", err)
+			logger.ErrorContext(ctx, "search_meme marshal result failed", "error", err)
 			return mcp.NewToolResultError(fmt.Sprintf("结果序列化失败: %v", err)), nil
 		}
 
-		return mcp.NewToolResultText(buf.String()), nil
+		if !args.WithImages {
+			return mcp.NewToolResultText(buf.String()), nil
+		}
+
+		// 附带图片内容
+		count := args.ImageCount
+		if count <= 0 {
+			count = 3
+		} else if count > maxImageCount {
+			count = maxImageCount
+		}
+
+		fetched, imageErrs := images.fetchMemeImages(ctx, result.Memes, count, args.MaxSize)
+		for u, e := range imageErrs {
+			logger.WarnContext(ctx, "search_meme fetch image failed", "url", u, "error", e)
+		}
+
+		content := []mcp.Content{mcp.NewTextContent(buf.String())}
+		for _, img := range fetched {
+			if img != nil {
+				content = append(content, newImageContent(img))
+			}
+		}
+
+		return &mcp.CallToolResult{Content: content}, nil
+	}
+}
+
+// GetMemeImageArgs get_meme_image 工具的参数
+type GetMemeImageArgs struct {
+	URL     string `json:"url"`
+	MaxSize int    `json:"max_size,omitempty"`
+}
+
+// NewGetMemeImageTool 创建 get_meme_image MCP Tool
+func NewGetMemeImageTool() mcp.Tool {
+	return mcp.NewTool(
+		"get_meme_image",
+		mcp.WithDescription("下载指定表情包图片并以图片内容返回，用于在客户端内联展示 search_meme 返回的结果。"),
+		mcp.WithString("url",
+			mcp.Required(),
+			mcp.Description("图片链接，通常为 search_meme 结果中的 url 字段"),
+		),
+		mcp.WithNumber("max_size",
+			mcp.Description("可选，图片最长边像素，超过则缩放 (仅支持 gif/png/jpg)，默认不缩放"),
+		),
+	)
+}
+
+// HandleGetMemeImage 处理 get_meme_image 请求
+func HandleGetMemeImage(images *ImageFetcher) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logger.DebugContext(ctx, "get_meme_image request received", "arguments", request.Params.Arguments)
+
+		var args GetMemeImageArgs
+		argsBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+		if err := json.Unmarshal(argsBytes, &args); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("参数解析失败: %v", err)), nil
+		}
+
+		if _, err := checkImageURL(args.URL); err != nil || !core.IsValidImageURL(args.URL) {
+			return mcp.NewToolResultError("url 参数不是有效的图片链接"), nil
+		}
+
+		img, err := images.fetchImage(ctx, args.URL, args.MaxSize)
+		if err != nil {
+			logger.WarnContext(ctx, "get_meme_image fetch failed", "url", args.URL, "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("图片获取失败: %v", err)), nil
+		}
+
+		logger.InfoContext(ctx, "get_meme_image fetched", "mime_type", img.MIMEType, "width", img.Width, "height", img.Height, "bytes", len(img.Data))
+
+		meta, err := json.Marshal(map[string]any{
+			"url":       args.URL,
+			"mime_type": img.MIMEType,
+			"width":     img.Width,
+			"height":    img.Height,
+			"bytes":     len(img.Data),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("结果序列化失败: %v", err)), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.NewTextContent(string(meta)),
+				newImageContent(img),
+			},
+		}, nil
+	}
+}
+
+// progressReporter 返回按源上报进度的回调，客户端未提供 progressToken 时仅记录日志
+func progressReporter(ctx context.Context, request mcp.CallToolRequest) func(core.SourceResult) {
+	var token mcp.ProgressToken
+	if request.Params.Meta != nil {
+		token = request.Params.Meta.ProgressToken
+	}
+	srv := server.ServerFromContext(ctx)
+
+	return func(r core.SourceResult) {
+		message := fmt.Sprintf("%s: %d results in %dms", r.SourceID, len(r.Memes), r.DurationMs)
+		if r.Err != nil {
+			message = fmt.Sprintf("%s: failed (%v)", r.SourceID, r.Err)
+		}
+		logger.DebugContext(ctx, "search_meme progress", "done", r.Done, "total", r.Total, "message", message)
+
+		if token == nil || srv == nil {
+			return
+		}
+		err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      r.Done,
+			"total":         r.Total,
+			"message":       message,
+		})
+		if err != nil {
+			logger.WarnContext(ctx, "search_meme send progress failed", "error", err)
+		}
 	}
 }
 
@@ -104,22 +262,20 @@ func HandleSearchMeme(registry *core.Registry) func(ctx context.Context, request
 func NewListSourcesTool() mcp.Tool {
 	return mcp.NewTool(
 		"list_sources",
-		mcp.WithDescription("列出所有可用的表情包数据源及其信息"),
+		mcp.WithDescription("列出所有可用的表情包数据源及其信息，包括是否支持翻页、单页上限、是否需要图片代理、是否返回尺寸、支持的浏览模式等能力描述"),
 	)
 }
 
 // HandleListSources 处理 list_sources 请求
 func HandleListSources(registry *core.Registry) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		fmt.Fprintf(os.Stderr, "[ListSources] Request received\n")
-
 		infos := sources.GetAllSourceInfo(registry)
 
-		fmt.Fprintf(os.Stderr, "[ListSources] Found %d sources\n", len(infos))
+		logger.DebugContext(ctx, "list_sources completed", "sources", len(infos))
 
 		resultJSON, err := json.MarshalIndent(infos, "", "  ")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[ListSources] Failed to marshal result: %v\n", err)
+			logger.ErrorContext(ctx, "list_sources marshal result failed", "error", err)
 			return mcp.NewToolResultError(fmt.Sprintf("结果序列化失败: %v", err)), nil
 		}
 
@@ -0,0 +1,334 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter 将结束的 Span 批量发送到后端
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+	Shutdown(ctx context.Context) error
+}
+
+// ============ OTLP/JSON 编码 ============
+
+// scopeName 上报的 instrumentation scope 名称
+const scopeName = "github.com/shadow/meme"
+
+type otlpExport struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue OTLP/JSON 中 64 位整数以字符串表示
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func encodeAttrs(attrs []Attr) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpAnyValue
+		switch val := a.Value.(type) {
+		case string:
+			v.StringValue = &val
+		case bool:
+			v.BoolValue = &val
+		case int64:
+			s := strconv.FormatInt(val, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &val
+		default:
+			s := fmt.Sprint(val)
+			v.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: a.Key, Value: v})
+	}
+	return kvs
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// encodeOTLP 按 OTLP/JSON (ExportTraceServiceRequest) 格式编码
+func encodeOTLP(serviceName string, spans []*Span) ([]byte, error) {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		end, attrs, events, status, message := s.snapshot()
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(end),
+			Attributes:        encodeAttrs(attrs),
+			Status:            otlpStatus{Code: status, Message: message},
+		}
+		if !s.ParentID.IsZero() {
+			span.ParentSpanID = s.ParentID.String()
+		}
+		for _, e := range events {
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: unixNano(e.Time),
+				Name:         e.Name,
+				Attributes:   encodeAttrs(e.Attrs),
+			})
+		}
+		out = append(out, span)
+	}
+
+	return json.Marshal(otlpExport{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: encodeAttrs([]Attr{String("service.name", serviceName)}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: out,
+			}},
+		}},
+	})
+}
+
+// ============ 文件导出 ============
+
+// FileExporter 以 JSON Lines 追加写入文件，每行一个 OTLP/JSON 请求体
+type FileExporter struct {
+	serviceName string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileExporter 打开 (或创建) 导出文件
+func NewFileExporter(path, serviceName string) (*FileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open trace file failed: %w", err)
+	}
+	return &FileExporter{serviceName: serviceName, file: file}, nil
+}
+
+func (e *FileExporter) Export(ctx context.Context, spans []*Span) error {
+	data, err := encodeOTLP(e.serviceName, spans)
+	if err != nil {
+		return fmt.Errorf("encode spans failed: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write trace file failed: %w", err)
+	}
+	return nil
+}
+
+func (e *FileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.file.Close()
+}
+
+// ============ OTLP/HTTP 导出 ============
+
+// OTLPExporter 通过 OTLP/HTTP (JSON 编码) 发送到 Collector
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	client      *http.Client
+}
+
+// NewOTLPExporter 创建 OTLP/HTTP 导出器，endpoint 为完整的 traces 地址，如 http://localhost:4318/v1/traces
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     headers,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	data, err := encodeOTLP(e.serviceName, spans)
+	if err != nil {
+		return fmt.Errorf("encode spans failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export spans failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// multiExporter 同时导出到多个后端
+type multiExporter []Exporter
+
+func (m multiExporter) Export(ctx context.Context, spans []*Span) error {
+	var firstErr error
+	for _, e := range m {
+		if err := e.Export(ctx, spans); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m multiExporter) Shutdown(ctx context.Context) error {
+	var firstErr error
+	for _, e := range m {
+		if err := e.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ============ 配置 ============
+
+// Config 链路追踪配置
+type Config struct {
+	ServiceName  string            // 上报的服务名
+	OTLPEndpoint string            // OTLP/HTTP traces 地址，为空则不发送
+	OTLPHeaders  map[string]string // 发送时附带的请求头，如鉴权
+	File         string            // 导出文件路径，为空则不写文件
+}
+
+// ConfigFromEnv 从环境变量读取配置
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT 优先于 OTEL_EXPORTER_OTLP_ENDPOINT (后者会追加 /v1/traces)
+func ConfigFromEnv(defaultServiceName string) Config {
+	cfg := Config{
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		File:        os.Getenv("MEME_TRACE_FILE"),
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		cfg.OTLPEndpoint = endpoint
+	} else if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		cfg.OTLPEndpoint = strings.TrimRight(endpoint, "/") + "/v1/traces"
+	}
+
+	// 格式: key1=value1,key2=value2
+	if raw := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); raw != "" {
+		cfg.OTLPHeaders = make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				cfg.OTLPHeaders[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+
+	return cfg
+}
+
+// Setup 按配置创建全局 Tracer，未配置任何导出目标时不导出
+func Setup(cfg Config) (*Tracer, error) {
+	var exporters multiExporter
+	if cfg.File != "" {
+		exporter, err := NewFileExporter(cfg.File, cfg.ServiceName)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+	if cfg.OTLPEndpoint != "" {
+		exporters = append(exporters, NewOTLPExporter(cfg.OTLPEndpoint, cfg.ServiceName, cfg.OTLPHeaders))
+	}
+
+	var tracer *Tracer
+	if len(exporters) > 0 {
+		tracer = NewTracer(exporters)
+	} else {
+		tracer = NewTracer(nil)
+	}
+	SetDefault(tracer)
+	return tracer, nil
+}
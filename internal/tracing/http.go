@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+
+	"github.com/shadow/meme/internal/redact"
+)
+
+// Transport 为每个 HTTP 请求创建 Client Span，并将 DNS、建连、TLS 握手与等待首字节拆分为子 Span
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport 包装 base，base 为 nil 时使用 http.DefaultTransport
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := StartKind(req.Context(), "HTTP "+req.Method, KindClient,
+		String("http.method", req.Method),
+		String("http.url", redact.URL(req.URL.String())),
+		String("net.peer.name", req.URL.Hostname()),
+	)
+	defer span.End()
+
+	phases := &httpPhases{ctx: ctx, connects: make(map[string]*Span)}
+	ctx = httptrace.WithClientTrace(ctx, phases.clientTrace())
+
+	resp, err := t.Base.RoundTrip(req.WithContext(ctx))
+	phases.endAll()
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(StatusError, resp.Status)
+	}
+	return resp, nil
+}
+
+// httpPhases 记录 httptrace 回调对应的子 Span
+type httpPhases struct {
+	ctx context.Context
+
+	mu       sync.Mutex
+	dns      *Span
+	connects map[string]*Span // 并发拨号时按地址区分
+	tls      *Span
+	wait     *Span
+}
+
+func (p *httpPhases) start(name string, attrs ...Attr) *Span {
+	_, span := Start(p.ctx, name, attrs...)
+	return span
+}
+
+func (p *httpPhases) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			SpanFromContext(p.ctx).SetAttributes(Bool("http.conn_reused", info.Reused))
+		},
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.dns = p.start("dns", String("net.peer.name", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.dns != nil {
+				p.dns.SetAttributes(Int("dns.addrs", len(info.Addrs)))
+				p.dns.RecordError(info.Err)
+				p.dns.End()
+				p.dns = nil
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.connects[addr] = p.start("connect", String("net.transport", network), String("net.peer.addr", addr))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if span, ok := p.connects[addr]; ok {
+				span.RecordError(err)
+				span.End()
+				delete(p.connects, addr)
+			}
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.tls = p.start("tls")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.tls != nil {
+				p.tls.SetAttributes(String("tls.version", tls.VersionName(state.Version)))
+				p.tls.RecordError(err)
+				p.tls.End()
+				p.tls = nil
+			}
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.wait = p.start("server_wait")
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.wait != nil {
+				p.wait.End()
+				p.wait = nil
+			}
+		},
+	}
+}
+
+// endAll 结束因出错而未完成的阶段
+func (p *httpPhases) endAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, span := range []*Span{p.dns, p.tls, p.wait} {
+		if span != nil {
+			span.End()
+		}
+	}
+	for _, span := range p.connects {
+		span.End()
+	}
+}
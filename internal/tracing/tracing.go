@@ -0,0 +1,344 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/shadow/meme/internal/redact"
+)
+
+// SpanKind 与 OTLP 中的 Span.SpanKind 取值一致
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode 与 OTLP 中的 Status.StatusCode 取值一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// TraceID 16 字节的链路标识
+type TraceID [16]byte
+
+// SpanID 8 字节的 Span 标识
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// IsZero 是否为空标识
+func (s SpanID) IsZero() bool { return s == SpanID{} }
+
+// Attr Span 属性，Value 支持 string、bool、int、int64、float64
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attr          { return Attr{Key: key, Value: value} }
+func Int(key string, value int) Attr         { return Attr{Key: key, Value: int64(value)} }
+func Int64(key string, value int64) Attr     { return Attr{Key: key, Value: value} }
+func Bool(key string, value bool) Attr       { return Attr{Key: key, Value: value} }
+func Float64(key string, value float64) Attr { return Attr{Key: key, Value: value} }
+
+// Event Span 内的时间点事件
+type Event struct {
+	Name  string
+	Time  time.Time
+	Attrs []Attr
+}
+
+// Span 一次操作的耗时记录，所有方法对 nil 安全
+type Span struct {
+	tracer *Tracer
+
+	TraceID  TraceID
+	SpanID   SpanID
+	ParentID SpanID
+	Name     string
+	Kind     SpanKind
+	Start    time.Time
+
+	mu      sync.Mutex
+	end     time.Time
+	attrs   []Attr
+	events  []Event
+	status  StatusCode
+	message string
+	ended   bool
+}
+
+// SetAttributes 设置属性
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// AddEvent 记录事件
+func (s *Span) AddEvent(name string, attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{Name: name, Time: time.Now(), Attrs: attrs})
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+	s.message = message
+}
+
+// RecordError 记录错误并将状态置为 Error，err 为 nil 时忽略
+// 错误信息中的 URL 会先脱敏 (如 *url.Error 携带的完整请求地址)
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	msg := redact.Text(err.Error())
+	s.AddEvent("exception", String("exception.message", msg))
+	s.SetStatus(StatusError, msg)
+}
+
+// End 结束 Span 并交给导出器，重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
+
+// snapshot 返回结束后的只读数据
+func (s *Span) snapshot() (end time.Time, attrs []Attr, events []Event, status StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end, s.attrs, s.events, s.status, s.message
+}
+
+// ============ Context ============
+
+type spanKey struct{}
+
+// ContextWithSpan 将 Span 放入 context
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext 取出当前 Span，不存在时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceIDFromContext 返回当前链路的 TraceID 十六进制串，不存在时返回空串
+func TraceIDFromContext(ctx context.Context) string {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.TraceID.String()
+	}
+	return ""
+}
+
+// ============ Tracer ============
+
+// Tracer 创建 Span 并批量导出
+type Tracer struct {
+	exporter Exporter
+
+	queue chan *Span
+	flush chan chan struct{}
+	once  sync.Once
+}
+
+// 批量导出参数
+const (
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 2 * time.Second
+)
+
+// NewTracer 创建 Tracer，exporter 为 nil 时只生成标识、不导出
+func NewTracer(exporter Exporter) *Tracer {
+	t := &Tracer{exporter: exporter}
+	if exporter != nil {
+		t.queue = make(chan *Span, queueSize)
+		t.flush = make(chan chan struct{})
+		go t.run()
+	}
+	return t
+}
+
+// Start 创建子 Span，ctx 中没有 Span 时开启新的链路
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		Name:   name,
+		Kind:   kind,
+		Start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		newID(span.TraceID[:])
+	}
+	newID(span.SpanID[:])
+	return ContextWithSpan(ctx, span), span
+}
+
+// randRead 生成标识使用的随机源，测试中可替换
+var randRead = rand.Read
+
+// newID 用随机字节填充标识；crypto/rand 失败时退回 math/rand，
+// 保证不会生成全零的标识 (OTLP 中视为无效，整条链路会被后端丢弃)
+func newID(id []byte) {
+	if _, err := randRead(id); err == nil && !isZero(id) {
+		return
+	}
+	for isZero(id) {
+		for i := range id {
+			id[i] = byte(mrand.Uint32())
+		}
+	}
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue 将结束的 Span 放入导出队列，队列已满时丢弃
+func (t *Tracer) enqueue(span *Span) {
+	if t.exporter == nil {
+		return
+	}
+	select {
+	case t.queue <- span:
+	default:
+	}
+}
+
+// run 后台批量导出，满批或定时刷新
+func (t *Tracer) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// 导出失败只能丢弃，不影响业务
+		_ = t.exporter.Export(context.Background(), batch)
+		batch = nil
+	}
+
+	for {
+		select {
+		case span := <-t.queue:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				export()
+			}
+		case ack := <-t.flush:
+			// 先取完队列中已有的 Span
+			for drained := false; !drained; {
+				select {
+				case span := <-t.queue:
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			export()
+			close(ack)
+		case <-ticker.C:
+			export()
+		}
+	}
+}
+
+// Shutdown 导出剩余的 Span 并关闭导出器
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+
+	var err error
+	t.once.Do(func() {
+		ack := make(chan struct{})
+		select {
+		case t.flush <- ack:
+			<-ack
+		case <-ctx.Done():
+		}
+		err = t.exporter.Shutdown(ctx)
+	})
+	return err
+}
+
+// ============ 全局 Tracer ============
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer = NewTracer(nil)
+)
+
+// SetDefault 替换全局 Tracer
+func SetDefault(t *Tracer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultTracer = t
+}
+
+// Default 返回全局 Tracer
+func Default() *Tracer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+// Start 使用全局 Tracer 创建内部 Span
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return Default().Start(ctx, name, KindInternal, attrs...)
+}
+
+// StartKind 使用全局 Tracer 创建指定类型的 Span
+func StartKind(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind, attrs...)
+}
@@ -0,0 +1,451 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memExporter 将导出的 Span 保存在内存中
+type memExporter struct {
+	mu       sync.Mutex
+	batches  [][]*Span
+	shutdown bool
+	exported chan int // 每次导出时写入该批的 Span 数量
+}
+
+func newMemExporter() *memExporter {
+	return &memExporter{exported: make(chan int, 16)}
+}
+
+func (e *memExporter) Export(_ context.Context, spans []*Span) error {
+	e.mu.Lock()
+	e.batches = append(e.batches, spans)
+	e.mu.Unlock()
+	select {
+	case e.exported <- len(spans):
+	default:
+	}
+	return nil
+}
+
+func (e *memExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+// spans 返回所有已导出的 Span
+func (e *memExporter) spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var all []*Span
+	for _, b := range e.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+// useDefault 在测试期间替换全局 Tracer
+func useDefault(t *testing.T, tracer *Tracer) {
+	t.Helper()
+	old := Default()
+	SetDefault(tracer)
+	t.Cleanup(func() { SetDefault(old) })
+}
+
+func TestStartChildSpan(t *testing.T) {
+	tracer := NewTracer(nil)
+
+	ctx, root := tracer.Start(context.Background(), "root", KindServer)
+	_, child := tracer.Start(ctx, "child", KindInternal)
+	_, other := tracer.Start(context.Background(), "other", KindInternal)
+
+	if root.TraceID == (TraceID{}) || root.SpanID.IsZero() {
+		t.Fatalf("root span has zero ids: trace %s span %s", root.TraceID, root.SpanID)
+	}
+	if !root.ParentID.IsZero() {
+		t.Errorf("root ParentID = %s, want zero", root.ParentID)
+	}
+	if child.TraceID != root.TraceID {
+		t.Errorf("child TraceID = %s, want parent's %s", child.TraceID, root.TraceID)
+	}
+	if child.ParentID != root.SpanID {
+		t.Errorf("child ParentID = %s, want parent SpanID %s", child.ParentID, root.SpanID)
+	}
+	if child.SpanID == root.SpanID {
+		t.Errorf("child reused parent SpanID %s", child.SpanID)
+	}
+	if other.TraceID == root.TraceID {
+		t.Errorf("span without parent joined trace %s", root.TraceID)
+	}
+	if got := TraceIDFromContext(ctx); got != root.TraceID.String() {
+		t.Errorf("TraceIDFromContext = %q, want %q", got, root.TraceID)
+	}
+}
+
+func TestStartRandomFailure(t *testing.T) {
+	old := randRead
+	t.Cleanup(func() { randRead = old })
+
+	for name, read := range map[string]func([]byte) (int, error){
+		"error": func([]byte) (int, error) { return 0, errors.New("entropy unavailable") },
+		"zeros": func(b []byte) (int, error) { clear(b); return len(b), nil },
+	} {
+		t.Run(name, func(t *testing.T) {
+			randRead = read
+			ctx, root := NewTracer(nil).Start(context.Background(), "root", KindInternal)
+			_, child := NewTracer(nil).Start(ctx, "child", KindInternal)
+			if root.TraceID == (TraceID{}) || root.SpanID.IsZero() || child.SpanID.IsZero() {
+				t.Errorf("ids = trace %s, root %s, child %s, want non-zero", root.TraceID, root.SpanID, child.SpanID)
+			}
+			if child.SpanID == root.SpanID {
+				t.Errorf("child and root share SpanID %s", root.SpanID)
+			}
+		})
+	}
+}
+
+func TestEncodeOTLP(t *testing.T) {
+	tracer := NewTracer(nil)
+	ctx, root := tracer.Start(context.Background(), "search", KindServer)
+	_, child := tracer.Start(ctx, "HTTP GET", KindClient,
+		String("http.url", "https://example.com/"),
+		Int("http.status_code", 503),
+		Bool("cached", false),
+		Float64("ratio", 0.5),
+	)
+	child.RecordError(errors.New("unexpected status code: 503"))
+	child.End()
+	root.End()
+
+	data, err := encodeOTLP("meme-test", []*Span{child, root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []map[string]any `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				Spans []map[string]any `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected structure: %s", data)
+	}
+	rs := req.ResourceSpans[0]
+	if got := mustJSON(t, rs.Resource.Attributes); got != `[{"key":"service.name","value":{"stringValue":"meme-test"}}]` {
+		t.Errorf("resource attributes = %s", got)
+	}
+	if rs.ScopeSpans[0].Scope.Name != scopeName {
+		t.Errorf("scope name = %q, want %q", rs.ScopeSpans[0].Scope.Name, scopeName)
+	}
+
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	got, parent := spans[0], spans[1]
+
+	hex32 := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	hex16 := regexp.MustCompile(`^[0-9a-f]{16}$`)
+	digits := regexp.MustCompile(`^[1-9][0-9]*$`)
+	if id, _ := got["traceId"].(string); !hex32.MatchString(id) || id != root.TraceID.String() {
+		t.Errorf("traceId = %v, want 32 hex digits of %s", got["traceId"], root.TraceID)
+	}
+	if id, _ := got["spanId"].(string); !hex16.MatchString(id) {
+		t.Errorf("spanId = %v, want 16 hex digits", got["spanId"])
+	}
+	if got["parentSpanId"] != root.SpanID.String() {
+		t.Errorf("parentSpanId = %v, want %s", got["parentSpanId"], root.SpanID)
+	}
+	if _, ok := parent["parentSpanId"]; ok {
+		t.Errorf("root span has parentSpanId %v", parent["parentSpanId"])
+	}
+	// 枚举以数字编码，纳秒时间戳以字符串编码
+	if got["kind"] != float64(3) || parent["kind"] != float64(2) {
+		t.Errorf("kind = %v / %v, want 3 (client) / 2 (server)", got["kind"], parent["kind"])
+	}
+	for _, field := range []string{"startTimeUnixNano", "endTimeUnixNano"} {
+		if ts, _ := got[field].(string); !digits.MatchString(ts) {
+			t.Errorf("%s = %#v, want a decimal string", field, got[field])
+		}
+	}
+	if got := mustJSON(t, got["status"]); got != `{"code":2,"message":"unexpected status code: 503"}` {
+		t.Errorf("status = %s", got)
+	}
+	if got := mustJSON(t, parent["status"]); got != `{}` {
+		t.Errorf("unset status = %s, want {}", got)
+	}
+
+	wantAttrs := `[` +
+		`{"key":"http.url","value":{"stringValue":"https://example.com/"}},` +
+		`{"key":"http.status_code","value":{"intValue":"503"}},` +
+		`{"key":"cached","value":{"boolValue":false}},` +
+		`{"key":"ratio","value":{"doubleValue":0.5}}]`
+	if got := mustJSON(t, got["attributes"]); got != wantAttrs {
+		t.Errorf("attributes = %s\nwant %s", got, wantAttrs)
+	}
+
+	events, _ := got["events"].([]any)
+	if len(events) != 1 {
+		t.Fatalf("events = %v, want the recorded exception", got["events"])
+	}
+	event := events[0].(map[string]any)
+	if event["name"] != "exception" || !digits.MatchString(event["timeUnixNano"].(string)) {
+		t.Errorf("event = %v", event)
+	}
+	if got := mustJSON(t, event["attributes"]); got != `[{"key":"exception.message","value":{"stringValue":"unexpected status code: 503"}}]` {
+		t.Errorf("event attributes = %s", got)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTracerBatching(t *testing.T) {
+	exporter := newMemExporter()
+	tracer := NewTracer(exporter)
+
+	// 满批立即导出，无需等待定时刷新
+	for i := 0; i < batchSize+3; i++ {
+		_, span := tracer.Start(context.Background(), "op", KindInternal)
+		span.End()
+		span.End() // 重复结束不会重复导出
+	}
+	select {
+	case n := <-exporter.exported:
+		if n != batchSize {
+			t.Errorf("first batch has %d spans, want %d", n, batchSize)
+		}
+	case <-time.After(flushInterval / 2):
+		t.Fatal("full batch was not exported before the flush interval")
+	}
+
+	// Shutdown 导出队列中剩余的 Span 并关闭导出器
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(exporter.spans()); got != batchSize+3 {
+		t.Errorf("exported %d spans, want %d", got, batchSize+3)
+	}
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if !exporter.shutdown {
+		t.Error("exporter was not shut down")
+	}
+}
+
+func TestTracerShutdownFlushesQueue(t *testing.T) {
+	exporter := newMemExporter()
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root", KindServer)
+	_, child := tracer.Start(ctx, "child", KindInternal)
+	child.End()
+	root.End()
+	// 未结束的 Span 不导出
+	tracer.Start(context.Background(), "unfinished", KindInternal)
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 重复关闭无效
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, s := range exporter.spans() {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "child,root" {
+		t.Errorf("exported spans = %s, want child,root", got)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	useDefault(t, Default())
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	tracer, err := Setup(Config{ServiceName: "meme-test", File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, root := Start(context.Background(), "search")
+	_, child := Start(ctx, "parse")
+	child.End()
+	root.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want one OTLP request per batch", len(lines))
+	}
+	var req otlpExport
+	if err := json.Unmarshal([]byte(lines[0]), &req); err != nil {
+		t.Fatalf("invalid JSON line: %v\n%s", err, lines[0])
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 || spans[0].Name != "parse" || spans[1].Name != "search" {
+		t.Fatalf("spans = %+v, want parse and search", spans)
+	}
+	if spans[0].ParentSpanID != spans[1].SpanID || spans[0].TraceID != spans[1].TraceID {
+		t.Errorf("parse span is not a child of search: %+v", spans)
+	}
+	if *req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "meme-test" {
+		t.Errorf("service name = %+v", req.ResourceSpans[0].Resource.Attributes)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	t.Setenv("MEME_TRACE_FILE", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer abc, x-tenant = meme")
+
+	cfg := ConfigFromEnv("meme-cli")
+	if cfg.ServiceName != "meme-cli" || cfg.OTLPEndpoint != "http://collector:4318/v1/traces" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.OTLPHeaders["authorization"] != "Bearer abc" || cfg.OTLPHeaders["x-tenant"] != "meme" {
+		t.Errorf("headers = %v", cfg.OTLPHeaders)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://collector:4318/custom")
+	if cfg := ConfigFromEnv("meme-cli"); cfg.OTLPEndpoint != "http://collector:4318/custom" {
+		t.Errorf("OTLPEndpoint = %q, want the traces endpoint to take precedence", cfg.OTLPEndpoint)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		body []byte
+		hdr  http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hdr = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/v1/traces", "meme-test", map[string]string{"Authorization": "Bearer abc"})
+	_, span := NewTracer(nil).Start(context.Background(), "search", KindServer)
+	span.End()
+	if err := exporter.Export(context.Background(), []*Span{span}); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if hdr.Get("Content-Type") != "application/json" || hdr.Get("Authorization") != "Bearer abc" {
+		t.Errorf("headers = %v", hdr)
+	}
+	var req otlpExport
+	if err := json.Unmarshal(body, &req); err != nil || req.ResourceSpans[0].ScopeSpans[0].Spans[0].Name != "search" {
+		t.Errorf("body = %s (err %v)", body, err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	if err := NewOTLPExporter(failing.URL, "meme-test", nil).Export(context.Background(), []*Span{span}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Export to failing collector = %v, want status error", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	exporter := newMemExporter()
+	tracer := NewTracer(exporter)
+	useDefault(t, tracer)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, root := Start(context.Background(), "search")
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/search?msToken=secret", nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	root.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]*Span)
+	for _, s := range exporter.spans() {
+		byName[s.Name] = s
+	}
+	httpSpan := byName["HTTP GET"]
+	if httpSpan == nil {
+		t.Fatalf("no HTTP span in %v", byName)
+	}
+	if httpSpan.Kind != KindClient || httpSpan.ParentID != root.SpanID || httpSpan.TraceID != root.TraceID {
+		t.Errorf("HTTP span kind %d parent %s trace %s, want client child of %s", httpSpan.Kind, httpSpan.ParentID, httpSpan.TraceID, root.SpanID)
+	}
+	_, attrs, _, status, _ := httpSpan.snapshot()
+	values := make(map[string]any)
+	for _, a := range attrs {
+		values[a.Key] = a.Value
+	}
+	if values["http.status_code"] != int64(503) || status != StatusError {
+		t.Errorf("attrs = %v, status = %d, want 503 recorded as error", values, status)
+	}
+	if url, _ := values["http.url"].(string); strings.Contains(url, "secret") {
+		t.Errorf("http.url not redacted: %s", url)
+	}
+	for _, phase := range []string{"connect", "server_wait"} {
+		if s := byName[phase]; s == nil || s.ParentID != httpSpan.SpanID {
+			t.Errorf("%s span missing or not a child of the HTTP span", phase)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// AcceptEncoding 请求时声明支持的压缩格式，与 DecodeBody 支持的格式一致
+const AcceptEncoding = "gzip, deflate, br, zstd"
+
+// DecodingTransport 声明支持的压缩格式并解压响应体，响应中的 Content-Encoding 与 Content-Length 会被移除
+// 请求已设置 Accept-Encoding 时保留原值，响应同样按 Content-Encoding 解压
+type DecodingTransport struct {
+	Base http.RoundTripper
+}
+
+// NewDecodingTransport 包装 base，base 为 nil 时使用 http.DefaultTransport
+func NewDecodingTransport(base http.RoundTripper) *DecodingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &DecodingTransport{Base: base}
+}
+
+func (t *DecodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	encoding := resp.Header.Get("Content-Encoding")
+	if encoding == "" || req.Method == http.MethodHead {
+		return resp, nil
+	}
+
+	body, err := DecodeBody(resp.Body, encoding)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// DecodeBody 按 Content-Encoding 解压 body，多个编码 (如 "gzip, br") 按相反顺序依次解压
+// 支持 gzip、deflate (zlib 或裸 deflate)、br 与 zstd，关闭返回值时同时关闭 body
+func DecodeBody(body io.ReadCloser, contentEncoding string) (io.ReadCloser, error) {
+	decoded := &decodedBody{Reader: body, closers: []io.Closer{body}}
+
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encoding := strings.ToLower(strings.TrimSpace(encodings[i])); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			r, err := gzip.NewReader(decoded.Reader)
+			if err != nil {
+				decoded.Close()
+				return nil, fmt.Errorf("create gzip reader failed: %w", err)
+			}
+			decoded.push(r, r)
+		case "deflate":
+			r, err := newDeflateReader(decoded.Reader)
+			if err != nil {
+				decoded.Close()
+				return nil, fmt.Errorf("create deflate reader failed: %w", err)
+			}
+			decoded.push(r, r)
+		case "br":
+			decoded.push(brotli.NewReader(decoded.Reader), nil)
+		case "zstd":
+			d, err := zstd.NewReader(decoded.Reader, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				decoded.Close()
+				return nil, fmt.Errorf("create zstd reader failed: %w", err)
+			}
+			r := d.IOReadCloser()
+			decoded.push(r, r)
+		default:
+			decoded.Close()
+			return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+	}
+	return decoded, nil
+}
+
+// newDeflateReader HTTP 的 deflate 按规范是 zlib 格式，但不少服务器直接发送裸 deflate 数据，按头部区分
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+	// zlib 头：CM = 8 且 (CMF<<8 | FLG) 是 31 的倍数
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// decodedBody 多层解压后的响应体，关闭时由内向外关闭各层
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) push(r io.Reader, c io.Closer) {
+	b.Reader = r
+	if c != nil {
+		b.closers = append(b.closers, c)
+	}
+}
+
+func (b *decodedBody) Close() error {
+	var first error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if err := b.closers[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// DecodeCharset 将 HTML 页面转换为 UTF-8，返回转换后的内容与识别出的编码名
+// 依次根据 BOM、Content-Type 的 charset 参数与页面中的 <meta> 声明判断编码 (gb2312 按 GBK 处理)；
+// 都没有声明时，内容是合法 UTF-8 则按 UTF-8，否则按 GB18030 (兼容 GBK/GB2312) 处理
+func DecodeCharset(content []byte, contentType string) ([]byte, string, error) {
+	enc, name, certain := charset.DetermineEncoding(content, contentType)
+	if !certain && name == "windows-1252" {
+		// 未找到声明，DetermineEncoding 默认的 windows-1252 不适用于中文站点
+		if utf8.Valid(content) {
+			return content, "utf-8", nil
+		}
+		enc, name = simplifiedchinese.GB18030, "gb18030"
+	}
+	if name == "utf-8" {
+		return content, name, nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, name, fmt.Errorf("decode %s failed: %w", name, err)
+	}
+	return decoded, name, nil
+}
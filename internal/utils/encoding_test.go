@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+const samplePage = "<html><body><a title=\"猫猫表情\">表情包</a></body></html>"
+
+// compress 按 encoding 压缩 data，用于构造测试响应
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %s", encoding)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			header := strings.TrimPrefix(encoding, "raw-")
+			body, err := DecodeBody(io.NopCloser(bytes.NewReader(compress(t, encoding, []byte(samplePage)))), header)
+			if err != nil {
+				t.Fatalf("DecodeBody: %v", err)
+			}
+			defer body.Close()
+			if got, err := io.ReadAll(body); err != nil || string(got) != samplePage {
+				t.Errorf("decoded = %q, %v", got, err)
+			}
+		})
+	}
+
+	t.Run("stacked", func(t *testing.T) {
+		data := compress(t, "br", compress(t, "gzip", []byte(samplePage)))
+		body, err := DecodeBody(io.NopCloser(bytes.NewReader(data)), "gzip, br")
+		if err != nil {
+			t.Fatalf("DecodeBody: %v", err)
+		}
+		if got, _ := io.ReadAll(body); string(got) != samplePage {
+			t.Errorf("decoded = %q", got)
+		}
+	})
+
+	if _, err := DecodeBody(io.NopCloser(strings.NewReader(samplePage)), "compress"); err == nil {
+		t.Error("want error for unsupported encoding")
+	}
+}
+
+func TestDecodingTransport(t *testing.T) {
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(compress(t, "br", []byte(samplePage)))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: NewDecodingTransport(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if accept != AcceptEncoding {
+		t.Errorf("Accept-Encoding = %q, want %q", accept, AcceptEncoding)
+	}
+	if resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 || !resp.Uncompressed {
+		t.Errorf("response still marked as compressed: %v", resp.Header)
+	}
+	if got, _ := io.ReadAll(resp.Body); string(got) != samplePage {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestDecodeCharset(t *testing.T) {
+	gbk := func(s string) []byte {
+		b, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatalf("encode gbk: %v", err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name        string
+		content     []byte
+		contentType string
+		charset     string
+	}{
+		{"utf-8", []byte(samplePage), "text/html", "utf-8"},
+		{"header", gbk(samplePage), "text/html; charset=GBK", "gbk"},
+		{"meta", gbk(`<html><head><meta http-equiv="Content-Type" content="text/html; charset=gb2312"></head>` + samplePage[6:]), "text/html", "gbk"},
+		{"undeclared", gbk(samplePage), "", "gb18030"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, err := DecodeCharset(tt.content, tt.contentType)
+			if err != nil {
+				t.Fatalf("DecodeCharset: %v", err)
+			}
+			if name != tt.charset {
+				t.Errorf("charset = %s, want %s", name, tt.charset)
+			}
+			if !strings.Contains(string(got), "猫猫表情") {
+				t.Errorf("decoded = %q", got)
+			}
+		})
+	}
+}
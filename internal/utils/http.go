@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+)
+
+// LoggingTransport 以 debug 级别记录每个 HTTP 请求与响应，URL 中的敏感参数会被脱敏
+type LoggingTransport struct {
+	Base http.RoundTripper
+}
+
+// NewLoggingTransport 包装 base，base 为 nil 时使用 http.DefaultTransport
+func NewLoggingTransport(base http.RoundTripper) *LoggingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &LoggingTransport{Base: base}
+}
+
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	Request(ctx, req.Method, req.URL.String())
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		httpLogger.WarnContext(ctx, "http request failed",
+			"method", req.Method,
+			"url", req.URL.String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"error", err.Error(),
+		)
+		return nil, err
+	}
+
+	Response(ctx, resp.StatusCode, time.Since(start), resp.ContentLength)
+	return resp, nil
+}
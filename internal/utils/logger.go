@@ -1,77 +1,287 @@
 package utils
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/shadow/meme/internal/redact"
+	"github.com/shadow/meme/internal/tracing"
 )
 
 // LogLevel 日志级别
-type LogLevel int
+type LogLevel = slog.Level
 
 const (
-	LogLevelDebug LogLevel = iota
-	LogLevelInfo
-	LogLevelWarn
-	LogLevelError
+	LogLevelDebug = slog.LevelDebug
+	LogLevelInfo  = slog.LevelInfo
+	LogLevelWarn  = slog.LevelWarn
+	LogLevelError = slog.LevelError
 )
 
+// LogOptions 日志配置
+type LogOptions struct {
+	Level  LogLevel            // 全局级别
+	Levels map[string]LogLevel // 按组件覆盖的级别
+	JSON   bool                // 是否输出 JSON
+	Output io.Writer           // 输出目标，默认 Stderr (Stdio 模式下 Stdout 用于 MCP 协议)
+}
+
+// LogOptionsFromEnv 从环境变量读取日志配置
+// LOG_LEVEL: debug/info/warn/error；LOG_LEVELS: 按组件覆盖，如 sources=debug,tools=warn；LOG_FORMAT: text 或 json
+func LogOptionsFromEnv() LogOptions {
+	opts := LogOptions{
+		Level:  LogLevelInfo,
+		Levels: make(map[string]LogLevel),
+		JSON:   strings.EqualFold(os.Getenv("LOG_FORMAT"), "json"),
+	}
+	if level, ok := ParseLogLevel(os.Getenv("LOG_LEVEL")); ok {
+		opts.Level = level
+	}
+	for _, pair := range strings.Split(os.Getenv("LOG_LEVELS"), ",") {
+		component, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if level, ok := ParseLogLevel(raw); ok {
+			opts.Levels[strings.TrimSpace(component)] = level
+		}
+	}
+	return opts
+}
+
+// ParseLogLevel 解析级别名称，不区分大小写
+func ParseLogLevel(s string) (LogLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LogLevelDebug, true
+	case "info":
+		return LogLevelInfo, true
+	case "warn", "warning":
+		return LogLevelWarn, true
+	case "error":
+		return LogLevelError, true
+	}
+	return LogLevelInfo, false
+}
+
 var (
-	// CurrentLogLevel 当前日志级别，可通过环境变量 LOG_LEVEL 设置
-	CurrentLogLevel = LogLevelInfo
+	logMu      sync.RWMutex
+	logOptions LogOptions
+	logBase    slog.Handler
 )
 
 func init() {
-	// 从环境变量读取日志级别
-	level := os.Getenv("LOG_LEVEL")
-	switch level {
-	case "debug", "DEBUG":
-		CurrentLogLevel = LogLevelDebug
-	case "info", "INFO":
-		CurrentLogLevel = LogLevelInfo
-	case "warn", "WARN":
-		CurrentLogLevel = LogLevelWarn
-	case "error", "ERROR":
-		CurrentLogLevel = LogLevelError
-	}
-}
-
-func log(level LogLevel, prefix string, format string, args ...interface{}) {
-	if level < CurrentLogLevel {
+	ConfigureLogging(LogOptionsFromEnv())
+}
+
+// ConfigureLogging 替换全局日志配置，已创建的组件 Logger 立即生效
+func ConfigureLogging(opts LogOptions) {
+	if opts.Output == nil {
+		opts.Output = os.Stderr
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:       slog.LevelDebug, // 级别由组件 Handler 过滤
+		ReplaceAttr: redactAttr,
+	}
+	var base slog.Handler
+	if opts.JSON {
+		base = slog.NewJSONHandler(opts.Output, handlerOpts)
+	} else {
+		base = slog.NewTextHandler(opts.Output, handlerOpts)
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	logOptions = opts
+	logBase = base
+}
+
+// SetLogLevel 调整全局级别，component 非空时只调整该组件
+func SetLogLevel(component string, level LogLevel) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if component == "" {
+		logOptions.Level = level
 		return
 	}
+	levels := make(map[string]LogLevel, len(logOptions.Levels)+1)
+	for k, v := range logOptions.Levels {
+		levels[k] = v
+	}
+	levels[component] = level
+	logOptions.Levels = levels
+}
+
+// Logger 返回指定组件的 Logger，如 sources、tools、core
+func Logger(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// componentHandler 按组件过滤级别，并附加请求ID与链路ID
+type componentHandler struct {
+	component string
+	ops       []func(slog.Handler) slog.Handler // WithAttrs/WithGroup，在输出时依次应用
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	logMu.RLock()
+	defer logMu.RUnlock()
+	min, ok := logOptions.Levels[h.component]
+	if !ok {
+		min = logOptions.Level
+	}
+	return level >= min
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	logMu.RLock()
+	base := logBase
+	logMu.RUnlock()
+
+	attrs := []slog.Attr{slog.String("component", h.component)}
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if id := tracing.TraceIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("trace_id", id))
+	}
+
+	handler := base.WithAttrs(attrs)
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *componentHandler) with(op func(slog.Handler) slog.Handler) *componentHandler {
+	ops := append(append([]func(slog.Handler) slog.Handler(nil), h.ops...), op)
+	return &componentHandler{component: h.component, ops: ops}
+}
+
+// ============ 请求ID ============
+
+type requestIDKey struct{}
+
+// NewRequestID 生成随机请求ID
+func NewRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithRequestID 将请求ID放入 context，同一次搜索的所有日志共享该ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
 
-	timestamp := time.Now().Format("15:04:05.000")
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(os.Stderr, "[%s] %s %s\n", timestamp, prefix, msg)
+// RequestIDFromContext 取出请求ID，不存在时返回空串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// EnsureRequestID context 中没有请求ID时生成一个
+func EnsureRequestID(ctx context.Context) context.Context {
+	if RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, NewRequestID())
+}
+
+// ============ 脱敏 ============
+
+// redactedValue 脱敏后的占位符
+const redactedValue = redact.Placeholder
+
+// IsSensitiveKey 判断日志字段、请求头或 URL 参数名是否敏感
+func IsSensitiveKey(key string) bool {
+	return redact.IsSensitiveKey(key)
 }
 
+// RedactURL 将 URL 中敏感的查询参数与用户信息替换为占位符，无法解析时原样返回
+func RedactURL(raw string) string {
+	return redact.URL(raw)
+}
+
+// redactAttr 对敏感字段整体脱敏，对字符串、error 与 Stringer 中出现的 URL 只脱敏敏感参数
+// error 等非字符串值按其文本输出，避免 *url.Error 这类错误把完整请求地址带进日志
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, redactedValue)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); strings.Contains(s, "://") {
+			return slog.String(a.Key, redact.Text(s))
+		}
+	case slog.KindAny:
+		var s string
+		switch v := a.Value.Any().(type) {
+		case error:
+			s = v.Error()
+		case fmt.Stringer:
+			s = v.String()
+		default:
+			return a
+		}
+		if strings.Contains(s, "://") {
+			return slog.String(a.Key, redact.Text(s))
+		}
+	}
+	return a
+}
+
+// ============ 兼容旧接口 ============
+
+var defaultLogger = Logger("app")
+var httpLogger = Logger("http")
+
 // Debug 调试日志
 func Debug(format string, args ...interface{}) {
-	log(LogLevelDebug, "🔍 DEBUG", format, args...)
+	defaultLogger.Debug(fmt.Sprintf(format, args...))
 }
 
 // Info 信息日志
 func Info(format string, args ...interface{}) {
-	log(LogLevelInfo, "ℹ️  INFO", format, args...)
+	defaultLogger.Info(fmt.Sprintf(format, args...))
 }
 
 // Warn 警告日志
 func Warn(format string, args ...interface{}) {
-	log(LogLevelWarn, "⚠️  WARN", format, args...)
+	defaultLogger.Warn(fmt.Sprintf(format, args...))
 }
 
 // Error 错误日志
 func Error(format string, args ...interface{}) {
-	log(LogLevelError, "❌ ERROR", format, args...)
+	defaultLogger.Error(fmt.Sprintf(format, args...))
 }
 
-// Request 请求日志 (特殊格式)
-func Request(method, url string) {
-	log(LogLevelDebug, "🌐 REQ", "%s %s", method, url)
+// Request 请求日志
+func Request(ctx context.Context, method, url string) {
+	httpLogger.DebugContext(ctx, "http request", "method", method, "url", url)
 }
 
-// Response 响应日志 (特殊格式)
-func Response(status int, duration time.Duration, size int) {
-	log(LogLevelDebug, "📥 RES", "status=%d duration=%v size=%d bytes", status, duration, size)
+// Response 响应日志
+func Response(ctx context.Context, status int, duration time.Duration, size int64) {
+	httpLogger.DebugContext(ctx, "http response", "status", status, "duration_ms", duration.Milliseconds(), "bytes", size)
 }
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLoggerRedaction(t *testing.T) {
+	secretURL := "https://www.douyin.com/aweme/v1/web/im/resource/emoticon/search?keyword=cat&msToken=tok123"
+	urlErr := &url.Error{Op: "Get", URL: secretURL, Err: errors.New("dial tcp: connection refused")}
+
+	tests := []struct {
+		name string
+		args []any
+	}{
+		{"string url", []any{"url", secretURL}},
+		{"url error", []any{"error", urlErr}},
+		{"wrapped url error", []any{"error", errors.Join(errors.New("search failed"), urlErr)}},
+		{"url value", []any{"target", mustParseURL(t, secretURL)}},
+		{"sensitive key", []any{"token", "tok123"}},
+	}
+	for _, tt := range tests {
+		for _, asJSON := range []bool{false, true} {
+			var buf bytes.Buffer
+			ConfigureLogging(LogOptions{Output: &buf, JSON: asJSON, Level: LogLevelDebug})
+			Logger("test").InfoContext(context.Background(), "request", tt.args...)
+
+			out := buf.String()
+			if strings.Contains(out, "tok123") {
+				t.Errorf("%s (json=%v): secret leaked: %s", tt.name, asJSON, out)
+			}
+			if !strings.Contains(out, "REDACTED") {
+				t.Errorf("%s (json=%v): missing placeholder: %s", tt.name, asJSON, out)
+			}
+		}
+	}
+	ConfigureLogging(LogOptionsFromEnv())
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}